@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+func astCommand(args []string) int {
+	flags := flag.NewFlagSet("ast", flag.ExitOnError)
+	expression := flags.String("e", "", "print the AST of this expression instead of a file")
+	asJSON := flags.Bool("json", false, "print the AST as JSON")
+	fromJSON := flags.Bool("from-json", false, "read the file as a JSON AST instead of Lox source")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox ast [-json] [-e expression | [-from-json] file]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	lox := runner.LoxRunner{ErrorOutput: os.Stderr}
+	printer := runner.AstPrinter{}
+
+	if *expression != "" {
+		expr := lox.ParseExpression(*expression)
+		if lox.HadError {
+			return 65
+		}
+		if *asJSON {
+			return printJSON(runner.MarshalAst([]runner.Statement{&runner.ExpressionStatement{Expression: expr}}))
+		}
+		fmt.Println(printer.PrintExpression(expr))
+		return 0
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 64
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 66
+	}
+
+	var stmts []runner.Statement
+	if *fromJSON {
+		stmts, err = runner.UnmarshalAst(data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 65
+		}
+	} else {
+		stmts = lox.Parse(string(data))
+		if lox.HadError {
+			return 65
+		}
+	}
+
+	if *asJSON {
+		return printJSON(runner.MarshalAst(stmts))
+	}
+	fmt.Println(printer.Print(stmts))
+	return 0
+}
+
+func printJSON(data []byte, err error) int {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 70
+	}
+	fmt.Println(string(data))
+	return 0
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rdtharri/go-lox/dap"
+)
+
+func dapCommand(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox dap")
+		fmt.Fprintln(flags.Output(), "Serves the Debug Adapter Protocol over stdin and stdout.")
+	}
+	flags.Parse(args)
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+package dap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+type stepMode int
+
+const (
+	modeContinue stepMode = iota
+	modeStepIn
+	modeStepOver
+	modeStepOut
+	// modeAbort resumes a paused program only so that it can be cancelled.
+	modeAbort
+)
+
+var errNotStopped = errors.New("the program is not stopped")
+
+// debugger decides, from the interpreter's BeforeStatement hook, when the
+// program should stop, and parks it there until the client resumes it.
+type debugger struct {
+	server  *Server
+	resumed chan stepMode
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	entry       bool
+	paused      bool
+	stopped     bool
+	mode        stepMode
+	stepLine    int
+	stepDepth   int
+	lastLine    int
+	lastDepth   int
+	frames      []runner.Frame
+	references  map[int]*runner.Environment
+}
+
+func newDebugger(server *Server) *debugger {
+	return &debugger{
+		server:      server,
+		resumed:     make(chan stepMode),
+		breakpoints: make(map[int]bool),
+	}
+}
+
+func (d *debugger) beforeStatement(interpreter *runner.Interpreter, stmt runner.Statement) {
+	// A block shares its line with whatever opened it, so stopping on it
+	// would only stop twice in the same place.
+	if _, ok := stmt.(*runner.BlockStatement); ok {
+		return
+	}
+
+	frames := interpreter.StackTrace()
+	line, depth := frames[0].Line, len(frames)
+
+	d.mu.Lock()
+	reason := d.stopReason(line, depth)
+	d.lastLine, d.lastDepth = line, depth
+	if reason == "" {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	d.frames = frames
+	d.references = make(map[int]*runner.Environment)
+	d.mu.Unlock()
+
+	d.server.event("stopped", map[string]interface{}{
+		"reason":            reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+	mode := <-d.resumed
+
+	d.mu.Lock()
+	d.mode = mode
+	d.stepLine, d.stepDepth = line, depth
+	d.frames = nil
+	d.references = nil
+	d.mu.Unlock()
+}
+
+// stopReason is called with mu held and returns why the program should stop
+// at line, or "" if it should keep running.
+func (d *debugger) stopReason(line int, depth int) string {
+	if d.mode == modeAbort {
+		return ""
+	}
+	if d.entry {
+		d.entry = false
+		return "entry"
+	}
+	if d.paused {
+		d.paused = false
+		return "pause"
+	}
+
+	switch d.mode {
+	case modeStepIn:
+		if line != d.stepLine || depth != d.stepDepth {
+			return "step"
+		}
+	case modeStepOver:
+		if depth < d.stepDepth || (depth == d.stepDepth && line != d.stepLine) {
+			return "step"
+		}
+	case modeStepOut:
+		if depth < d.stepDepth {
+			return "step"
+		}
+	}
+
+	if d.breakpoints[line] && (line != d.lastLine || depth != d.lastDepth) {
+		return "breakpoint"
+	}
+	return ""
+}
+
+func (d *debugger) setBreakpoints(lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakpoints = make(map[int]bool)
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+func (d *debugger) pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused = true
+}
+
+// resume returns the function that restarts a stopped program in the given
+// mode.
+func (d *debugger) resume(mode stepMode) (func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopped {
+		return nil, errNotStopped
+	}
+	d.stopped = false
+	return func() { d.resumed <- mode }, nil
+}
+
+// release lets a stopped program run on without stopping again, so that it
+// notices it has been cancelled.
+func (d *debugger) release() {
+	d.mu.Lock()
+	stopped := d.stopped
+	d.stopped = false
+	d.mode = modeAbort
+	d.mu.Unlock()
+	if stopped {
+		d.resumed <- modeAbort
+	}
+}
+
+func (d *debugger) stackTrace() ([]runner.Frame, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopped {
+		return nil, errNotStopped
+	}
+	return d.frames, nil
+}
+
+// scopes lists one scope for each environment in a frame's chain, from the
+// innermost block out to the globals.
+func (d *debugger) scopes(frameID int) (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopped {
+		return nil, errNotStopped
+	}
+	if frameID < 0 || frameID >= len(d.frames) {
+		return nil, fmt.Errorf("unknown frame %v", frameID)
+	}
+
+	scopes := make([]Scope, 0)
+	for env := d.frames[frameID].Environment; env != nil; env = env.Enclosing {
+		name := "Block"
+		if env.Enclosing == nil {
+			name = "Globals"
+		} else if len(scopes) == 0 {
+			name = "Locals"
+		}
+		reference := len(d.references) + 1
+		d.references[reference] = env
+		scopes = append(scopes, Scope{Name: name, VariablesReference: reference})
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+func (d *debugger) variables(reference int) (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopped {
+		return nil, errNotStopped
+	}
+	env, ok := d.references[reference]
+	if !ok {
+		return nil, fmt.Errorf("unknown variables reference %v", reference)
+	}
+
+	names := make([]string, 0, len(env.Values))
+	for name, value := range env.Values {
+		if _, ok := value.(*runner.NativeFunction); ok {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variables := make([]Variable, 0, len(names))
+	for _, name := range names {
+		variables = append(variables, Variable{Name: name, Value: runner.Stringify(env.Values[name])})
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
@@ -0,0 +1,65 @@
+package dap
+
+// The subset of the Debug Adapter Protocol messages the server uses.
+
+type message struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+}
+
+type request struct {
+	message
+	Command   string                 `json:"command"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+type response struct {
+	message
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type event struct {
+	message
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+type Source struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type StackFrame struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type Breakpoint struct {
+	Verified bool `json:"verified"`
+	Line     int  `json:"line"`
+}
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
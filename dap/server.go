@@ -0,0 +1,294 @@
+// Package dap implements a Debug Adapter Protocol server for Lox programs
+// over a pair of streams, normally stdin and stdout.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+// threadID is the id of the only thread Lox programs have.
+const threadID = 1
+
+type Server struct {
+	reader *bufio.Reader
+
+	// mu guards writer and seq, which both the request loop and the
+	// running program write to.
+	mu     sync.Mutex
+	writer io.Writer
+	seq    int
+
+	program     string
+	source      string
+	stopOnEntry bool
+	debugger    *debugger
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	server := &Server{
+		reader: bufio.NewReader(in),
+		writer: out,
+	}
+	server.debugger = newDebugger(server)
+	return server
+}
+
+// Run serves requests until the client disconnects or closes the input.
+func (s *Server) Run() error {
+	defer s.stop()
+	for {
+		data, err := s.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return err
+		}
+		if req.Type != "request" {
+			continue
+		}
+
+		body, after, err := s.dispatch(req)
+		s.respond(req, body, err)
+		if after != nil {
+			after()
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// dispatch handles a request. Anything that must happen only once the client
+// has its response, such as resuming the program, is returned as after.
+func (s *Server) dispatch(req request) (body interface{}, after func(), err error) {
+	switch req.Command {
+	case "initialize":
+		body = map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsTerminateRequest":         true,
+		}
+		return body, func() { s.event("initialized", nil) }, nil
+	case "launch":
+		return nil, nil, s.launch(req.Arguments)
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req.Arguments)
+		return body, nil, err
+	case "configurationDone":
+		return nil, s.start, nil
+	case "threads":
+		return map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}}, nil, nil
+	case "stackTrace":
+		body, err = s.stackTrace()
+		return body, nil, err
+	case "scopes":
+		body, err = s.debugger.scopes(intArgument(req.Arguments, "frameId"))
+		return body, nil, err
+	case "variables":
+		body, err = s.debugger.variables(intArgument(req.Arguments, "variablesReference"))
+		return body, nil, err
+	case "continue":
+		after, err = s.debugger.resume(modeContinue)
+		return map[string]interface{}{"allThreadsContinued": true}, after, err
+	case "next":
+		after, err = s.debugger.resume(modeStepOver)
+		return nil, after, err
+	case "stepIn":
+		after, err = s.debugger.resume(modeStepIn)
+		return nil, after, err
+	case "stepOut":
+		after, err = s.debugger.resume(modeStepOut)
+		return nil, after, err
+	case "pause":
+		s.debugger.pause()
+		return nil, nil, nil
+	case "terminate", "disconnect":
+		return nil, s.stop, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported request %q", req.Command)
+}
+
+func (s *Server) launch(arguments map[string]interface{}) error {
+	program, _ := arguments["program"].(string)
+	if program == "" {
+		return errors.New("launch requires a program")
+	}
+	data, err := os.ReadFile(program)
+	if err != nil {
+		return err
+	}
+	s.program = program
+	s.source = string(data)
+	s.stopOnEntry, _ = arguments["stopOnEntry"].(bool)
+	return nil
+}
+
+func (s *Server) setBreakpoints(arguments map[string]interface{}) (interface{}, error) {
+	lines := make([]int, 0)
+	if requested, ok := arguments["breakpoints"].([]interface{}); ok {
+		for _, breakpoint := range requested {
+			if fields, ok := breakpoint.(map[string]interface{}); ok {
+				lines = append(lines, intArgument(fields, "line"))
+			}
+		}
+	}
+	s.debugger.setBreakpoints(lines)
+
+	breakpoints := make([]Breakpoint, 0, len(lines))
+	for _, line := range lines {
+		breakpoints = append(breakpoints, Breakpoint{Verified: true, Line: line})
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// start runs the launched program on its own goroutine. Its output is sent
+// to the client as output events, since the stream belongs to the protocol,
+// and for the same reason it reads its input from an empty stream.
+func (s *Server) start() {
+	if s.done != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.debugger.entry = s.stopOnEntry
+
+	go func() {
+		defer close(s.done)
+		lox := runner.LoxRunner{
+			Output:      outputWriter{s, "stdout"},
+			ErrorOutput: outputWriter{s, "stderr"},
+			Input:       strings.NewReader(""),
+			Hooks:       runner.Hooks{BeforeStatement: s.debugger.beforeStatement},
+		}
+		lox.Run(ctx, s.source)
+
+		exitCode := 0
+		if lox.Exited {
+			exitCode = lox.ExitCode
+		} else if lox.HadError {
+			exitCode = 65
+		} else if lox.HadRuntimeError {
+			exitCode = 70
+		}
+		s.event("exited", map[string]interface{}{"exitCode": exitCode})
+		s.event("terminated", nil)
+	}()
+}
+
+// stop cancels a running program and waits for it to finish.
+func (s *Server) stop() {
+	if s.done == nil {
+		return
+	}
+	s.cancel()
+	s.debugger.release()
+	<-s.done
+}
+
+func (s *Server) stackTrace() (interface{}, error) {
+	frames, err := s.debugger.stackTrace()
+	if err != nil {
+		return nil, err
+	}
+
+	source := Source{Name: filepath.Base(s.program), Path: s.program}
+	stackFrames := make([]StackFrame, 0, len(frames))
+	for id, frame := range frames {
+		stackFrames = append(stackFrames, StackFrame{
+			ID:     id,
+			Name:   frame.Name,
+			Source: source,
+			Line:   frame.Line,
+			Column: 1,
+		})
+	}
+	return map[string]interface{}{"stackFrames": stackFrames, "totalFrames": len(stackFrames)}, nil
+}
+
+func (s *Server) read() ([]byte, error) {
+	headers, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("bad Content-Length: %w", err)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Server) send(build func(seq int) interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	data, err := json.Marshal(build(s.seq))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(s.writer, "Content-Length: %v\r\n\r\n", len(data))
+	s.writer.Write(data)
+}
+
+func (s *Server) respond(req request, body interface{}, err error) {
+	s.send(func(seq int) interface{} {
+		resp := response{
+			message:    message{Seq: seq, Type: "response"},
+			RequestSeq: req.Seq,
+			Success:    err == nil,
+			Command:    req.Command,
+			Body:       body,
+		}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		return resp
+	})
+}
+
+func (s *Server) event(name string, body interface{}) {
+	s.send(func(seq int) interface{} {
+		return event{message: message{Seq: seq, Type: "event"}, Event: name, Body: body}
+	})
+}
+
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.server.event("output", map[string]interface{}{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
+
+func intArgument(arguments map[string]interface{}, name string) int {
+	value, _ := arguments[name].(float64)
+	return int(value)
+}
@@ -0,0 +1,330 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testProgram = `fun add(a, b) {
+  var sum = a + b;
+  return sum;
+}
+var x = 1;
+var y = add(x, 2);
+print y;
+`
+
+// received is a response or an event from the server.
+type received struct {
+	Type    string          `json:"type"`
+	Command string          `json:"command"`
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Event   string          `json:"event"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// client drives a Server running in the same process over pipes.
+type client struct {
+	t        *testing.T
+	writer   *io.PipeWriter
+	messages chan received
+	done     chan error
+	seq      int
+	output   string
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{
+		t:        t,
+		writer:   clientOut,
+		messages: make(chan received, 100),
+		done:     make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Run()
+		serverOut.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			length, _ := strconv.Atoi(headers.Get("Content-Length"))
+			data := make([]byte, length)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			var m received
+			if err := json.Unmarshal(data, &m); err == nil {
+				c.messages <- m
+			}
+		}
+	}()
+	t.Cleanup(func() { clientOut.Close() })
+	return c
+}
+
+func (c *client) send(command string, arguments interface{}) {
+	c.seq++
+	data, err := json.Marshal(map[string]interface{}{
+		"seq":       c.seq,
+		"type":      "request",
+		"command":   command,
+		"arguments": arguments,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	fmt.Fprintf(c.writer, "Content-Length: %v\r\n\r\n%s", len(data), data)
+}
+
+// next returns the next message other than an output event, whose text is
+// collected in c.output instead.
+func (c *client) next() received {
+	c.t.Helper()
+	for {
+		select {
+		case m, ok := <-c.messages:
+			if !ok {
+				c.t.Fatal("server closed the stream")
+			}
+			if m.Type == "event" && m.Event == "output" {
+				var body struct{ Output string }
+				json.Unmarshal(m.Body, &body)
+				c.output += body.Output
+				continue
+			}
+			return m
+		case <-time.After(5 * time.Second):
+			c.t.Fatal("timed out waiting for the server")
+		}
+	}
+}
+
+// request sends a request and decodes the body of its response into body,
+// unless body is nil.
+func (c *client) request(command string, arguments interface{}, body interface{}) {
+	c.t.Helper()
+	c.send(command, arguments)
+	m := c.next()
+	if m.Type != "response" || m.Command != command {
+		c.t.Fatalf("%v: got %+v, want its response", command, m)
+	}
+	if !m.Success {
+		c.t.Fatalf("%v failed: %v", command, m.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("%v: %v", command, err)
+		}
+	}
+}
+
+// expectEvent waits for the next message, which must be the named event.
+func (c *client) expectEvent(name string, body interface{}) {
+	c.t.Helper()
+	m := c.next()
+	if m.Type != "event" || m.Event != name {
+		c.t.Fatalf("got %+v, want a %v event", m, name)
+	}
+	if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("%v: %v", name, err)
+		}
+	}
+}
+
+// expectStop waits for the program to stop for reason and returns where it
+// stopped, innermost frame first.
+func (c *client) expectStop(reason string) []StackFrame {
+	c.t.Helper()
+	var stopped struct{ Reason string }
+	c.expectEvent("stopped", &stopped)
+	if stopped.Reason != reason {
+		c.t.Fatalf("stopped for %q, want %q", stopped.Reason, reason)
+	}
+	var trace struct{ StackFrames []StackFrame }
+	c.request("stackTrace", map[string]interface{}{"threadId": threadID}, &trace)
+	return trace.StackFrames
+}
+
+// variables lists the variables in each scope of a frame.
+func (c *client) variables(frameID int) map[string][]Variable {
+	c.t.Helper()
+	var scopes struct{ Scopes []Scope }
+	c.request("scopes", map[string]interface{}{"frameId": frameID}, &scopes)
+	variables := make(map[string][]Variable)
+	for _, scope := range scopes.Scopes {
+		var body struct{ Variables []Variable }
+		c.request("variables", map[string]interface{}{"variablesReference": scope.VariablesReference}, &body)
+		variables[scope.Name] = body.Variables
+	}
+	return variables
+}
+
+func lookup(variables []Variable, name string) (string, bool) {
+	for _, variable := range variables {
+		if variable.Name == name {
+			return variable.Value, true
+		}
+	}
+	return "", false
+}
+
+func lines(frames []StackFrame) []string {
+	names := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		names = append(names, fmt.Sprintf("%v:%v", frame.Name, frame.Line))
+	}
+	return names
+}
+
+func TestDebugSession(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "program.lox")
+	if err := os.WriteFile(program, []byte(testProgram), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+
+	c.request("initialize", map[string]interface{}{"adapterID": "go-lox"}, nil)
+	c.expectEvent("initialized", nil)
+	c.request("launch", map[string]interface{}{"program": program}, nil)
+	var breakpoints struct{ Breakpoints []Breakpoint }
+	c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": program},
+		"breakpoints": []map[string]int{{"line": 6}},
+	}, &breakpoints)
+	if want := []Breakpoint{{Verified: true, Line: 6}}; !reflect.DeepEqual(breakpoints.Breakpoints, want) {
+		t.Errorf("breakpoints = %+v, want %+v", breakpoints.Breakpoints, want)
+	}
+	c.request("configurationDone", nil, nil)
+
+	frames := c.expectStop("breakpoint")
+	if got, want := lines(frames), []string{"<script>:6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("at the breakpoint, stack = %v, want %v", got, want)
+	}
+	if frames[0].Source.Path != program {
+		t.Errorf("source = %+v, want %v", frames[0].Source, program)
+	}
+	// y is declared by the statement the program stopped on.
+	globals := c.variables(0)["Globals"]
+	if value, ok := lookup(globals, "x"); value != "1" || !ok {
+		t.Errorf("globals = %+v, want x = 1", globals)
+	}
+	if _, ok := lookup(globals, "y"); ok {
+		t.Errorf("globals = %+v, want no y yet", globals)
+	}
+
+	c.request("stepIn", map[string]interface{}{"threadId": threadID}, nil)
+	frames = c.expectStop("step")
+	if got, want := lines(frames), []string{"add:2", "<script>:6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after stepIn, stack = %v, want %v", got, want)
+	}
+	locals := c.variables(0)["Locals"]
+	if want := []Variable{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}; !reflect.DeepEqual(locals, want) {
+		t.Errorf("locals = %+v, want %+v", locals, want)
+	}
+
+	c.request("next", map[string]interface{}{"threadId": threadID}, nil)
+	frames = c.expectStop("step")
+	if got, want := lines(frames), []string{"add:3", "<script>:6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after next, stack = %v, want %v", got, want)
+	}
+	locals = c.variables(0)["Locals"]
+	if want := []Variable{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}, {Name: "sum", Value: "3"}}; !reflect.DeepEqual(locals, want) {
+		t.Errorf("locals = %+v, want %+v", locals, want)
+	}
+
+	c.request("stepOut", map[string]interface{}{"threadId": threadID}, nil)
+	frames = c.expectStop("step")
+	if got, want := lines(frames), []string{"<script>:7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after stepOut, stack = %v, want %v", got, want)
+	}
+
+	c.request("continue", map[string]interface{}{"threadId": threadID}, nil)
+	var exited struct{ ExitCode int }
+	c.expectEvent("exited", &exited)
+	c.expectEvent("terminated", nil)
+	if exited.ExitCode != 0 || c.output != "3\n" {
+		t.Errorf("exit code %v and output %q, want 0 and \"3\\n\"", exited.ExitCode, c.output)
+	}
+
+	c.request("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Run() = %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "program.lox")
+	if err := os.WriteFile(program, []byte("exit(3);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", nil, nil)
+	c.expectEvent("initialized", nil)
+	c.request("launch", map[string]interface{}{"program": program}, nil)
+	c.request("configurationDone", nil, nil)
+
+	var exited struct{ ExitCode int }
+	c.expectEvent("exited", &exited)
+	c.expectEvent("terminated", nil)
+	if exited.ExitCode != 3 {
+		t.Errorf("exit code = %v, want 3", exited.ExitCode)
+	}
+	c.request("disconnect", nil, nil)
+}
+
+func TestInput(t *testing.T) {
+	// The adapter's own stdin carries the protocol, so a program reading
+	// input must not see it.
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin.WriteString("protocol\n")
+	stdin.Seek(0, io.SeekStart)
+	saved := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() { os.Stdin = saved })
+
+	program := filepath.Join(t.TempDir(), "program.lox")
+	if err := os.WriteFile(program, []byte("print readLine();\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", nil, nil)
+	c.expectEvent("initialized", nil)
+	c.request("launch", map[string]interface{}{"program": program}, nil)
+	c.request("configurationDone", nil, nil)
+	c.expectEvent("exited", nil)
+	c.expectEvent("terminated", nil)
+	if c.output != "nil\n" {
+		t.Errorf("output = %q, want %q", c.output, "nil\n")
+	}
+	c.request("disconnect", nil, nil)
+}
+
+func TestResumeWhileRunning(t *testing.T) {
+	c := newClient(t)
+	c.send("continue", map[string]interface{}{"threadId": threadID})
+	if m := c.next(); m.Success || m.Message != errNotStopped.Error() {
+		t.Errorf("continue before launch = %+v, want it to fail", m)
+	}
+}
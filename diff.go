@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff turning before into after, found from the
+// longest common subsequence of their lines.
+func unifiedDiff(path string, before string, after string) string {
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %v\n+++ %v (formatted)\n", path, path)
+
+	for start := 0; start < len(ops); {
+		// Find the next change and grow the hunk around it until the gap to
+		// the following change is wider than two contexts.
+		change := start
+		for change < len(ops) && ops[change].kind == ' ' {
+			change++
+		}
+		if change == len(ops) {
+			break
+		}
+		from := change - diffContext
+		if from < start {
+			from = start
+		}
+		to := change
+		for to < len(ops) {
+			if ops[to].kind != ' ' {
+				to++
+				continue
+			}
+			gap := to
+			for gap < len(ops) && ops[gap].kind == ' ' {
+				gap++
+			}
+			if gap == len(ops) || gap-to > 2*diffContext {
+				break
+			}
+			to = gap
+		}
+		end := to + diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		writeHunk(&builder, ops, from, end)
+		start = end
+	}
+	return builder.String()
+}
+
+func writeHunk(builder *strings.Builder, ops []diffOp, from int, to int) {
+	beforeLine, afterLine := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			beforeLine++
+		}
+		if op.kind != '-' {
+			afterLine++
+		}
+	}
+
+	beforeCount, afterCount := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			beforeCount++
+		}
+		if op.kind != '-' {
+			afterCount++
+		}
+	}
+	if beforeCount == 0 {
+		beforeLine--
+	}
+	if afterCount == 0 {
+		afterLine--
+	}
+
+	fmt.Fprintf(builder, "@@ -%v,%v +%v,%v @@\n", beforeLine, beforeCount, afterLine, afterCount)
+	for _, op := range ops[from:to] {
+		builder.WriteByte(op.kind)
+		builder.WriteString(op.line)
+		builder.WriteString("\n")
+	}
+}
+
+func diffLines(before []string, after []string) []diffOp {
+	// common[i][j] is the length of the LCS of before[i:] and after[j:].
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(before)+len(after))
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			ops = append(ops, diffOp{' ', before[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			ops = append(ops, diffOp{'-', before[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', after[j]})
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		ops = append(ops, diffOp{'-', before[i]})
+	}
+	for ; j < len(after); j++ {
+		ops = append(ops, diffOp{'+', after[j]})
+	}
+	return ops
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
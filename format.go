@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result back to the source file")
+	showDiff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox fmt [-w] [-d] [-l] [path ...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "go-lox fmt: cannot use -w with standard input")
+			return 64
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 66
+		}
+		return formatSource("<standard input>", data, false, *showDiff, *list)
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (file != path && filepath.Ext(file) != ".lox") {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if code := formatSource(file, data, *write, *showDiff, *list); code != 0 {
+				status = code
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 66
+		}
+	}
+	return status
+}
+
+func formatSource(path string, data []byte, write, showDiff, list bool) int {
+	formatted, err := runner.Format(string(data))
+	if err != nil {
+		for _, message := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", path, message)
+		}
+		return 65
+	}
+	changed := !bytes.Equal(data, []byte(formatted))
+
+	if list && changed {
+		fmt.Println(path)
+	}
+	if showDiff && changed {
+		fmt.Print(unifiedDiff(path, string(data), formatted))
+	}
+	if write && changed {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 66
+		}
+		if err := os.WriteFile(path, []byte(formatted), info.Mode().Perm()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 73
+		}
+	}
+	if !write && !showDiff && !list {
+		fmt.Print(formatted)
+	}
+	return 0
+}
@@ -2,8 +2,10 @@ module github.com/rdtharri/go-lox
 
 go 1.19
 
+require golang.org/x/term v0.10.0
+
 require (
 	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
-	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
+	golang.org/x/sys v0.10.0 // indirect
 	golang.org/x/tools v0.1.12 // indirect
 )
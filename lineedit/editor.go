@@ -0,0 +1,496 @@
+// Package lineedit is a small readline-style line editor for interactive
+// terminals, with history, reverse search and tab completion.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns the candidates that could replace word, the text
+// immediately before the cursor that completion acts on.
+type Completer func(line string, word string) []string
+
+type Editor struct {
+	// History holds previously entered lines, oldest first.
+	History []string
+	// HistoryFile, when set, has every entered line appended to it.
+	HistoryFile string
+	// MaxHistory caps how many lines LoadHistory keeps. HistoryFile is cut
+	// back to that many once it grows to twice as many.
+	MaxHistory int
+	Complete   Completer
+
+	in     *os.File
+	reader *bufio.Reader
+	out    io.Writer
+	// fileLines counts the lines in HistoryFile, as far as the editor knows.
+	fileLines int
+}
+
+// New returns an editor reading keys from in, which should be a terminal,
+// and drawing on out.
+func New(in *os.File, out io.Writer) *Editor {
+	return &Editor{
+		MaxHistory: 1000,
+		in:         in,
+		reader:     bufio.NewReader(in),
+		out:        out,
+	}
+}
+
+// IsTerminal reports whether f is an interactive terminal the editor can
+// drive.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// LoadHistory reads HistoryFile into History. A missing file isn't an error.
+func (e *Editor) LoadHistory() error {
+	lines, err := e.readHistoryFile()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	e.fileLines = len(lines)
+	if len(lines) > e.MaxHistory {
+		lines = lines[len(lines)-e.MaxHistory:]
+	}
+	e.History = append(e.History, lines...)
+	return nil
+}
+
+// readHistoryFile returns the lines in HistoryFile, leaving out blank ones.
+func (e *Editor) readHistoryFile() ([]string, error) {
+	data, err := os.ReadFile(e.HistoryFile)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// trimHistoryFile rewrites HistoryFile with only its last MaxHistory lines.
+// The new contents are written to a temporary file and renamed over the old
+// one, so a failure part way leaves the history as it was.
+func (e *Editor) trimHistoryFile() error {
+	lines, err := e.readHistoryFile()
+	if err != nil {
+		return err
+	}
+	if len(lines) > e.MaxHistory {
+		lines = lines[len(lines)-e.MaxHistory:]
+	}
+	temp := e.HistoryFile + ".tmp"
+	if err := os.WriteFile(temp, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, e.HistoryFile); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	e.fileLines = len(lines)
+	return nil
+}
+
+func (e *Editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.History) > 0 && e.History[len(e.History)-1] == line {
+		return
+	}
+	e.History = append(e.History, line)
+
+	if e.HistoryFile == "" {
+		return
+	}
+	file, err := os.OpenFile(e.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprintln(file, line)
+	file.Close()
+	if err != nil {
+		return
+	}
+	e.fileLines++
+	if e.MaxHistory > 0 && e.fileLines > 2*e.MaxHistory {
+		e.trimHistoryFile()
+	}
+}
+
+// ReadLine shows prompt and edits a line until Enter is pressed. It returns
+// io.EOF for Ctrl-D on an empty line and ErrInterrupted for Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	state, err := term.MakeRaw(int(e.in.Fd()))
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(int(e.in.Fd()), state)
+
+	l := &line{editor: e, prompt: prompt, historyIndex: len(e.History)}
+	l.refresh()
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		if l.searching {
+			if done := l.search(key); done {
+				e.newline()
+				e.addHistory(string(l.buffer))
+				return string(l.buffer), nil
+			}
+			continue
+		}
+
+		switch key {
+		case keyEnter, keyCtrlJ:
+			e.newline()
+			e.addHistory(string(l.buffer))
+			return string(l.buffer), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C")
+			e.newline()
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(l.buffer) == 0 {
+				e.newline()
+				return "", io.EOF
+			}
+			l.deleteForward()
+		case keyBackspace, keyCtrlH:
+			l.deleteBackward()
+		case keyDelete:
+			l.deleteForward()
+		case keyLeft, keyCtrlB:
+			l.move(l.cursor - 1)
+		case keyRight, keyCtrlF:
+			l.move(l.cursor + 1)
+		case keyHome, keyCtrlA:
+			l.move(0)
+		case keyEnd, keyCtrlE:
+			l.move(len(l.buffer))
+		case keyUp, keyCtrlP:
+			l.recall(l.historyIndex - 1)
+		case keyDown, keyCtrlN:
+			l.recall(l.historyIndex + 1)
+		case keyCtrlK:
+			l.buffer = l.buffer[:l.cursor]
+			l.refresh()
+		case keyCtrlU:
+			l.buffer = l.buffer[l.cursor:]
+			l.cursor = 0
+			l.refresh()
+		case keyCtrlW:
+			l.deleteWord()
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+			l.refresh()
+		case keyCtrlR:
+			l.startSearch()
+		case keyTab:
+			l.complete()
+		default:
+			if key >= ' ' {
+				l.insert(key)
+			}
+		}
+	}
+}
+
+func (e *Editor) newline() {
+	fmt.Fprint(e.out, "\r\n")
+}
+
+// Keys that aren't printable characters. Escape sequences are mapped to
+// values outside the range of runes.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+
+	keyUp = iota + 0x110000
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+func (e *Editor) readKey() (rune, error) {
+	key, _, err := e.reader.ReadRune()
+	if err != nil || key != keyEscape {
+		return key, err
+	}
+
+	// A lone escape has nothing buffered after it.
+	if e.reader.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	next, _, err := e.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	sequence := ""
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		sequence += string(r)
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+	}
+
+	switch sequence {
+	case "A":
+		return keyUp, nil
+	case "B":
+		return keyDown, nil
+	case "C":
+		return keyRight, nil
+	case "D":
+		return keyLeft, nil
+	case "H", "1~", "7~":
+		return keyHome, nil
+	case "F", "4~", "8~":
+		return keyEnd, nil
+	case "3~":
+		return keyDelete, nil
+	}
+	return keyUnknown, nil
+}
+
+// line is the state of one call to ReadLine.
+type line struct {
+	editor *Editor
+	prompt string
+	buffer []rune
+	cursor int
+
+	historyIndex int
+	// draft is the unfinished line, kept while browsing history.
+	draft []rune
+
+	searching   bool
+	query       []rune
+	matchIndex  int
+	beforeQuery []rune
+}
+
+func (l *line) refresh() {
+	prompt := l.prompt
+	if l.searching {
+		prompt = fmt.Sprintf("(reverse-i-search)`%v': ", string(l.query))
+	}
+	out := l.editor.out
+	fmt.Fprintf(out, "\r%v%v\x1b[K", prompt, string(l.buffer))
+	fmt.Fprintf(out, "\r")
+	if column := len([]rune(prompt)) + l.cursor; column > 0 {
+		fmt.Fprintf(out, "\x1b[%vC", column)
+	}
+}
+
+func (l *line) insert(key rune) {
+	l.buffer = append(l.buffer[:l.cursor], append([]rune{key}, l.buffer[l.cursor:]...)...)
+	l.cursor++
+	l.refresh()
+}
+
+func (l *line) move(cursor int) {
+	if cursor < 0 || cursor > len(l.buffer) {
+		return
+	}
+	l.cursor = cursor
+	l.refresh()
+}
+
+func (l *line) deleteBackward() {
+	if l.cursor == 0 {
+		return
+	}
+	l.buffer = append(l.buffer[:l.cursor-1], l.buffer[l.cursor:]...)
+	l.cursor--
+	l.refresh()
+}
+
+func (l *line) deleteForward() {
+	if l.cursor == len(l.buffer) {
+		return
+	}
+	l.buffer = append(l.buffer[:l.cursor], l.buffer[l.cursor+1:]...)
+	l.refresh()
+}
+
+func (l *line) deleteWord() {
+	start := l.cursor
+	for start > 0 && l.buffer[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && l.buffer[start-1] != ' ' {
+		start--
+	}
+	l.buffer = append(l.buffer[:start], l.buffer[l.cursor:]...)
+	l.cursor = start
+	l.refresh()
+}
+
+func (l *line) recall(index int) {
+	history := l.editor.History
+	if index < 0 || index > len(history) {
+		return
+	}
+	if l.historyIndex == len(history) {
+		l.draft = append([]rune{}, l.buffer...)
+	}
+	l.historyIndex = index
+	if index == len(history) {
+		l.buffer = append([]rune{}, l.draft...)
+	} else {
+		l.buffer = []rune(history[index])
+	}
+	l.cursor = len(l.buffer)
+	l.refresh()
+}
+
+func (l *line) startSearch() {
+	l.searching = true
+	l.query = nil
+	l.matchIndex = len(l.editor.History)
+	l.beforeQuery = append([]rune{}, l.buffer...)
+	l.refresh()
+}
+
+// search handles a key while searching history and reports whether the
+// line has been submitted.
+func (l *line) search(key rune) bool {
+	switch key {
+	case keyEnter, keyCtrlJ:
+		l.searching = false
+		l.refresh()
+		return true
+	case keyCtrlG, keyEscape, keyCtrlC:
+		l.searching = false
+		l.buffer = l.beforeQuery
+		l.cursor = len(l.buffer)
+	case keyCtrlR:
+		l.findMatch(l.matchIndex - 1)
+	case keyBackspace, keyCtrlH:
+		if len(l.query) > 0 {
+			l.query = l.query[:len(l.query)-1]
+			l.findMatch(len(l.editor.History) - 1)
+		}
+	default:
+		if key >= ' ' && key < keyUp {
+			l.query = append(l.query, key)
+			l.findMatch(l.matchIndex)
+		} else {
+			// Any other key keeps the match to carry on editing it.
+			l.searching = false
+		}
+	}
+	l.refresh()
+	return false
+}
+
+// findMatch looks back through history from index for the query.
+func (l *line) findMatch(index int) {
+	history := l.editor.History
+	if index >= len(history) {
+		index = len(history) - 1
+	}
+	for ; index >= 0; index-- {
+		if strings.Contains(history[index], string(l.query)) {
+			l.matchIndex = index
+			l.buffer = []rune(history[index])
+			l.cursor = len(l.buffer)
+			return
+		}
+	}
+}
+
+func (l *line) complete() {
+	if l.editor.Complete == nil {
+		return
+	}
+	start := l.cursor
+	for start > 0 && isWordRune(l.buffer[start-1]) {
+		start--
+	}
+	word := string(l.buffer[start:l.cursor])
+	candidates := l.editor.Complete(string(l.buffer[:l.cursor]), word)
+	if len(candidates) == 0 {
+		return
+	}
+	sort.Strings(candidates)
+
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(candidates) == 1 {
+		prefix += " "
+	}
+
+	if prefix != word {
+		completion := []rune(prefix)
+		rest := append([]rune{}, l.buffer[l.cursor:]...)
+		l.buffer = append(append(l.buffer[:start], completion...), rest...)
+		l.cursor = start + len(completion)
+	} else {
+		l.editor.newline()
+		fmt.Fprint(l.editor.out, strings.Join(candidates, "  "))
+		l.editor.newline()
+	}
+	l.refresh()
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,168 @@
+package lineedit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newLine returns a line being edited with text before the cursor, for an
+// editor that draws into a buffer instead of a terminal.
+func newLine(history []string, text string) (*line, *bytes.Buffer) {
+	var out bytes.Buffer
+	editor := &Editor{History: history, MaxHistory: 1000, out: &out}
+	l := &line{editor: editor, prompt: "> ", buffer: []rune(text), cursor: len([]rune(text)), historyIndex: len(history)}
+	return l, &out
+}
+
+func TestRecall(t *testing.T) {
+	l, _ := newLine([]string{"first", "second"}, "draft")
+	steps := []struct {
+		index int
+		want  string
+	}{
+		{l.historyIndex - 1, "second"},
+		{0, "first"},
+		{-1, "first"}, // nothing before the oldest line
+		{1, "second"},
+		{2, "draft"}, // back to the unfinished line
+		{3, "draft"},
+	}
+	for _, step := range steps {
+		l.recall(step.index)
+		if got := string(l.buffer); got != step.want || l.cursor != len(l.buffer) {
+			t.Errorf("recall(%v): buffer %q, cursor %v, want %q at its end", step.index, got, l.cursor, step.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	l, out := newLine([]string{"print 1;", "var a = 2;", "print a;"}, "typed")
+	l.startSearch()
+	for _, key := range "pri" {
+		l.search(key)
+	}
+	if got := string(l.buffer); got != "print a;" {
+		t.Errorf("searching for pri found %q, want the latest match", got)
+	}
+	if !strings.Contains(out.String(), "(reverse-i-search)`pri': print a;") {
+		t.Errorf("search prompt not drawn: %q", out.String())
+	}
+
+	l.search(keyCtrlR)
+	if got := string(l.buffer); got != "print 1;" {
+		t.Errorf("Ctrl-R found %q, want the previous match", got)
+	}
+	l.search(keyCtrlR)
+	if got := string(l.buffer); got != "print 1;" {
+		t.Errorf("Ctrl-R with no earlier match gave %q, want the match kept", got)
+	}
+
+	l.search(keyBackspace)
+	l.search(keyBackspace)
+	l.search(keyBackspace)
+	l.search('=')
+	if got := string(l.buffer); got != "var a = 2;" {
+		t.Errorf("searching for = found %q", got)
+	}
+
+	l.search(keyEscape)
+	if l.searching || string(l.buffer) != "typed" {
+		t.Errorf("after Escape: searching %v, buffer %q, want the line from before the search", l.searching, string(l.buffer))
+	}
+
+	l.startSearch()
+	l.search('v')
+	if !l.search(keyEnter) || string(l.buffer) != "var a = 2;" {
+		t.Errorf("Enter didn't submit the match: %q", string(l.buffer))
+	}
+}
+
+func TestComplete(t *testing.T) {
+	words := []string{"print", "println", "push", "var"}
+	completer := func(line string, word string) []string {
+		matches := make([]string, 0)
+		for _, candidate := range words {
+			if strings.HasPrefix(candidate, word) {
+				matches = append(matches, candidate)
+			}
+		}
+		return matches
+	}
+
+	tests := []struct {
+		text   string
+		want   string
+		listed string
+	}{
+		{"1; va", "1; var ", ""},
+		{"pri", "print", ""},
+		{"print", "print", "print  println"},
+		{"x", "x", ""},
+	}
+	for _, test := range tests {
+		l, out := newLine(nil, test.text)
+		l.editor.Complete = completer
+		l.complete()
+		if got := string(l.buffer); got != test.want || l.cursor != len(l.buffer) {
+			t.Errorf("completing %q gave %q with cursor %v, want %q", test.text, got, l.cursor, test.want)
+		}
+		if listed := strings.Contains(out.String(), "\r\n"+test.listed+"\r\n"); test.listed != "" && !listed {
+			t.Errorf("completing %q didn't list %q: %q", test.text, test.listed, out.String())
+		}
+	}
+
+	// Completion replaces only the word before the cursor.
+	l, _ := newLine(nil, "pu")
+	l.buffer = []rune("pu(x)")
+	l.editor.Complete = completer
+	l.complete()
+	if got := string(l.buffer); got != "push (x)" || l.cursor != 5 {
+		t.Errorf("completing inside a line gave %q with cursor %v", got, l.cursor)
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("one\ntwo\n\nthree\nfour\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	editor := &Editor{HistoryFile: path, MaxHistory: 3}
+	if err := editor.LoadHistory(); err != nil {
+		t.Fatal(err)
+	}
+	// Blank lines don't count towards MaxHistory.
+	if want := []string{"two", "three", "four"}; !reflect.DeepEqual(editor.History, want) {
+		t.Errorf("History = %q, want %q", editor.History, want)
+	}
+
+	editor.addHistory("five")
+	editor.addHistory("five")
+	editor.addHistory("  ")
+	data, _ := os.ReadFile(path)
+	if want := "one\ntwo\n\nthree\nfour\nfive\n"; string(data) != want {
+		t.Errorf("history file = %q, want %q", data, want)
+	}
+
+	// Once the file holds twice MaxHistory lines, it's cut back to the last
+	// MaxHistory.
+	editor.addHistory("six")
+	data, _ = os.ReadFile(path)
+	if want := "one\ntwo\n\nthree\nfour\nfive\nsix\n"; string(data) != want {
+		t.Errorf("history file = %q, want %q", data, want)
+	}
+	editor.addHistory("seven")
+	data, _ = os.ReadFile(path)
+	if want := "five\nsix\nseven\n"; string(data) != want {
+		t.Errorf("trimmed history file = %q, want %q", data, want)
+	}
+
+	missing := &Editor{HistoryFile: filepath.Join(t.TempDir(), "missing"), MaxHistory: 3}
+	if err := missing.LoadHistory(); err != nil || len(missing.History) != 0 {
+		t.Errorf("loading a missing file: History %q, error %v", missing.History, err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+type lintResult struct {
+	File string `json:"file"`
+	runner.LintFinding
+}
+
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	enable := flags.String("enable", "", "comma-separated checks to run, instead of all of them")
+	disable := flags.String("disable", "", "comma-separated checks to skip")
+	asJSON := flags.Bool("json", false, "print findings as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox lint [-json] [-enable checks] [-disable checks] path ...")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "checks:")
+		checks := make([]string, 0, len(runner.LintChecks))
+		for check := range runner.LintChecks {
+			checks = append(checks, check)
+		}
+		sort.Strings(checks)
+		for _, check := range checks {
+			fmt.Fprintf(flags.Output(), "  %-18v%v\n", check, runner.LintChecks[check])
+		}
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 64
+	}
+
+	disabled := make(map[string]bool)
+	if *enable != "" {
+		for check := range runner.LintChecks {
+			disabled[check] = true
+		}
+		for _, check := range splitChecks(*enable) {
+			delete(disabled, check)
+		}
+	}
+	for _, check := range splitChecks(*disable) {
+		disabled[check] = true
+	}
+	for _, check := range append(splitChecks(*enable), splitChecks(*disable)...) {
+		if _, ok := runner.LintChecks[check]; !ok {
+			fmt.Fprintf(os.Stderr, "go-lox lint: unknown check %q\n", check)
+			return 64
+		}
+	}
+
+	status := 0
+	results := make([]lintResult, 0)
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (file != path && filepath.Ext(file) != ".lox") {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			lox := runner.LoxRunner{ErrorOutput: io.Discard}
+			stmts := lox.Parse(string(data))
+			if lox.HadError {
+				for _, syntaxError := range lox.Errors {
+					fmt.Fprintf(os.Stderr, "%v: %v\n", file, syntaxError)
+				}
+				status = 65
+				return nil
+			}
+			linter := runner.Linter{Disabled: disabled}
+			for _, finding := range linter.Lint(stmts) {
+				results = append(results, lintResult{File: file, LintFinding: finding})
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 66
+		}
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if code := printJSON(data, err); code != 0 {
+			return code
+		}
+	} else {
+		for _, result := range results {
+			fmt.Printf("%v:%v:%v: %v (%v)\n", result.File, result.Line, result.Column, result.Message, result.Check)
+		}
+	}
+
+	if status == 0 && len(results) > 0 {
+		status = 1
+	}
+	return status
+}
+
+func splitChecks(list string) []string {
+	checks := make([]string, 0)
+	for _, check := range strings.Split(list, ",") {
+		if check = strings.TrimSpace(check); check != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
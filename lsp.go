@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rdtharri/go-lox/lsp"
+)
+
+func lspCommand(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox lsp")
+		fmt.Fprintln(flags.Output(), "Serves the Language Server Protocol over stdin and stdout.")
+	}
+	flags.Parse(args)
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,349 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+// document is an open file and everything learnt from scanning, parsing and
+// resolving it. It is rebuilt on every change. While the text has syntax
+// errors, symbols is kept from the last version that parsed, so that
+// navigation keeps working as the user types.
+type document struct {
+	uri      string
+	text     string
+	tokens   []runner.Token
+	comments []runner.Token
+	stmts    []runner.Statement
+	errors   []runner.SyntaxError
+	symbols  *runner.SymbolTable
+}
+
+// newDocument builds the document for text. previous is the document's last
+// version, or nil when it was just opened.
+func newDocument(uri string, text string, previous *document) *document {
+	lox := runner.LoxRunner{ErrorOutput: io.Discard}
+	stmts := lox.Parse(text)
+
+	doc := &document{
+		uri:      uri,
+		text:     text,
+		tokens:   lox.Scanner.Tokens,
+		comments: lox.Scanner.Comments,
+		errors:   lox.Errors,
+	}
+	if !lox.HadError {
+		doc.stmts = stmts
+		doc.symbols = runner.IndexSymbols(stmts)
+	} else if previous != nil {
+		doc.symbols = previous.symbols
+	}
+	return doc
+}
+
+var handlers = map[string]func(*Server, json.RawMessage) (interface{}, error){
+	"initialize":                       (*Server).initialize,
+	"initialized":                      ignore,
+	"shutdown":                         (*Server).shutdownRequest,
+	"textDocument/didOpen":             (*Server).didOpen,
+	"textDocument/didChange":           (*Server).didChange,
+	"textDocument/didClose":            (*Server).didClose,
+	"textDocument/definition":          (*Server).definition,
+	"textDocument/references":          (*Server).references,
+	"textDocument/hover":               (*Server).hover,
+	"textDocument/documentSymbol":      (*Server).documentSymbol,
+	"textDocument/semanticTokens/full": (*Server).semanticTokens,
+}
+
+func ignore(*Server, json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	result := InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:       1, // full document on every change
+			DefinitionProvider:     true,
+			ReferencesProvider:     true,
+			HoverProvider:          true,
+			DocumentSymbolProvider: true,
+			SemanticTokensProvider: SemanticTokensOptions{
+				Legend: SemanticTokensLegend{
+					TokenTypes:     semanticTokenTypes,
+					TokenModifiers: semanticTokenModifiers,
+				},
+				Full: true,
+			},
+		},
+	}
+	result.ServerInfo.Name = "go-lox"
+	return result, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	s.update(p.TextDocument.URI, p.TextDocument.Text)
+	return nil, nil
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) > 0 {
+		s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	}
+	return nil, nil
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	delete(s.documents, p.TextDocument.URI)
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+	return nil, nil
+}
+
+func (s *Server) update(uri string, text string) {
+	doc := newDocument(uri, text, s.documents[uri])
+	s.documents[uri] = doc
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+func (doc *document) diagnostics() []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	for _, err := range doc.errors {
+		start := Position{Line: err.Line - 1, Character: err.Column - 1}
+		end := Position{Line: start.Line, Character: start.Character + err.Length}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    Range{Start: start, End: end},
+			Severity: SeverityError,
+			Source:   "go-lox",
+			Message:  err.Message,
+		})
+	}
+
+	if doc.stmts != nil {
+		linter := runner.Linter{}
+		for _, finding := range linter.Lint(doc.stmts) {
+			start := Position{Line: finding.Line - 1, Character: finding.Column - 1}
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    Range{Start: start, End: start},
+				Severity: SeverityWarning,
+				Code:     finding.Check,
+				Source:   "go-lox lint",
+				Message:  finding.Message,
+			})
+		}
+	}
+	return diagnostics
+}
+
+// symbolAt finds the document and the symbol under the cursor.
+func (s *Server) symbolAt(params TextDocumentPositionParams) (*document, *runner.Symbol, runner.Token) {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok || doc.symbols == nil {
+		return doc, nil, runner.Token{}
+	}
+	symbol, token, ok := doc.symbols.At(params.Position.Line+1, params.Position.Character+1, doc.tokens)
+	if !ok {
+		return doc, nil, runner.Token{}
+	}
+	return doc, symbol, token
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, symbol, _ := s.symbolAt(p)
+	if symbol == nil || symbol.Kind == runner.SymbolNative {
+		return nil, nil
+	}
+	return Location{URI: doc.uri, Range: tokenRange(symbol.Declaration)}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p ReferenceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, symbol, _ := s.symbolAt(p.TextDocumentPositionParams)
+	locations := make([]Location, 0)
+	if symbol == nil {
+		return locations, nil
+	}
+
+	if p.Context.IncludeDeclaration && symbol.Kind != runner.SymbolNative {
+		locations = append(locations, Location{URI: doc.uri, Range: tokenRange(symbol.Declaration)})
+	}
+	for _, reference := range symbol.References {
+		locations = append(locations, Location{URI: doc.uri, Range: tokenRange(reference)})
+	}
+	return locations, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	_, symbol, token := s.symbolAt(p)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	var declaration string
+	if _, ok := runner.Natives.Lookup(symbol.Name); ok && symbol.Kind == runner.SymbolNative {
+		declaration = "<native fn> " + symbol.Name
+	} else if symbol.Kind == runner.SymbolNative {
+		declaration = "<native namespace> " + symbol.Name
+	} else {
+		formatter := runner.Formatter{}
+		declaration = strings.TrimSpace(formatter.Format([]runner.Statement{symbol.Statement}))
+	}
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```lox\n" + declaration + "\n```"},
+		Range:    tokenRange(token),
+	}, nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	symbols := make([]DocumentSymbol, 0)
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok || doc.symbols == nil {
+		return symbols, nil
+	}
+
+	for _, symbol := range doc.symbols.Symbols {
+		if symbol.Kind == runner.SymbolNative || symbol.Depth > 0 {
+			continue
+		}
+		kind := SymbolKindVariable
+		if symbol.Kind == runner.SymbolFunction {
+			kind = SymbolKindFunction
+		}
+		symbols = append(symbols, DocumentSymbol{
+			Name:           symbol.Name,
+			Kind:           kind,
+			Range:          tokenRange(symbol.Declaration),
+			SelectionRange: tokenRange(symbol.Declaration),
+		})
+	}
+	return symbols, nil
+}
+
+var semanticTokenTypes = []string{"keyword", "variable", "function", "string", "number", "operator", "comment"}
+var semanticTokenModifiers = []string{"declaration", "defaultLibrary"}
+
+const (
+	modifierDeclaration = 1 << iota
+	modifierDefaultLibrary
+)
+
+func semanticType(token runner.Token) (int, bool) {
+	switch {
+	case token.Type >= runner.AND && token.Type <= runner.WHILE:
+		return 0, true
+	case token.Type == runner.IDENTIFIER:
+		return 1, true
+	case token.Type == runner.STRING || token.Type == runner.INTERPOLATION:
+		return 3, true
+	case token.Type == runner.NUMBER:
+		return 4, true
+	case token.Type >= runner.MINUS && token.Type <= runner.LESS_EQUAL && token.Type != runner.SEMICOLON:
+		return 5, true
+	case token.Type == runner.COMMENT:
+		return 6, true
+	}
+	return 0, false
+}
+
+func (s *Server) semanticTokens(params json.RawMessage) (interface{}, error) {
+	var p DocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	data := make([]int, 0)
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return SemanticTokens{Data: data}, nil
+	}
+
+	tokens := append(append([]runner.Token{}, doc.tokens...), doc.comments...)
+	sort.SliceStable(tokens, func(a, b int) bool {
+		if tokens[a].Line != tokens[b].Line {
+			return tokens[a].Line < tokens[b].Line
+		}
+		return tokens[a].Column < tokens[b].Column
+	})
+
+	line, character := 0, 0
+	for _, token := range tokens {
+		tokenType, ok := semanticType(token)
+		if !ok || strings.Contains(token.Lexeme, "\n") {
+			continue
+		}
+
+		modifiers := 0
+		if token.Type == runner.IDENTIFIER && doc.symbols != nil {
+			if symbol, _, ok := doc.symbols.At(token.Line, token.Column, doc.tokens); ok {
+				if symbol.Kind != runner.SymbolVariable {
+					tokenType = 2
+				}
+				if symbol.Kind == runner.SymbolNative {
+					modifiers |= modifierDefaultLibrary
+				} else if symbol.Declaration.Line == token.Line && symbol.Declaration.Column == token.Column {
+					modifiers |= modifierDeclaration
+				}
+			}
+		}
+
+		start := tokenRange(token).Start
+		if start.Line != line {
+			character = 0
+		}
+		data = append(data, start.Line-line, start.Character-character, utf16Length(token.Lexeme), tokenType, modifiers)
+		line, character = start.Line, start.Character
+	}
+	return SemanticTokens{Data: data}, nil
+}
+
+func tokenRange(token runner.Token) Range {
+	start := Position{Line: token.Line - 1, Character: token.Column - 1}
+	return Range{
+		Start: start,
+		End:   Position{Line: start.Line, Character: start.Character + utf16Length(token.Lexeme)},
+	}
+}
+
+func utf16Length(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
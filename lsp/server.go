@@ -0,0 +1,158 @@
+// Package lsp implements a Language Server Protocol server for Lox over a
+// pair of streams, normally stdin and stdout.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+var errExitWithoutShutdown = errors.New("exit received before shutdown")
+
+type Server struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		reader:    bufio.NewReader(in),
+		writer:    out,
+		documents: make(map[string]*document),
+	}
+}
+
+// Run serves requests until the client sends exit or closes the input. It
+// returns nil only for an orderly shutdown followed by exit.
+func (s *Server) Run() error {
+	for {
+		data, err := s.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) && s.shutdown {
+				return nil
+			}
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			s.respond(nil, nil, &responseError{codeParseError, err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+		s.handle(req)
+	}
+}
+
+func (s *Server) handle(req request) {
+	result, err := s.dispatch(req)
+	if req.ID == nil {
+		return
+	}
+	s.respond(req.ID, result, err)
+}
+
+func (s *Server) dispatch(req request) (result interface{}, rpcErr *responseError) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, rpcErr = nil, &responseError{codeInternalError, fmt.Sprint(r)}
+		}
+	}()
+
+	handler, ok := handlers[req.Method]
+	if !ok {
+		if req.ID == nil {
+			// Unknown notifications, such as $/cancelRequest, are ignored.
+			return nil, nil
+		}
+		return nil, &responseError{codeMethodNotFound, "method not found: " + req.Method}
+	}
+	if s.shutdown && req.Method != "shutdown" {
+		return nil, &responseError{codeInvalidRequest, "server is shutting down"}
+	}
+	result, err := handler(s, req.Params)
+	if err != nil {
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	return result, nil
+}
+
+func (s *Server) read() ([]byte, error) {
+	headers, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("bad Content-Length: %w", err)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Server) write(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(s.writer, "Content-Length: %v\r\n\r\n", len(data))
+	s.writer.Write(data)
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}, err *responseError) {
+	message := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+	if err != nil {
+		message["error"] = err
+	} else {
+		message["result"] = result
+	}
+	s.write(message)
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
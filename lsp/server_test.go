@@ -0,0 +1,267 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const testURI = "file:///test.lox"
+
+const testSource = `fun add(a, b) {
+  return a + b;
+}
+var total = add(1, 2);
+print total;
+`
+
+// session scripts a client: it queues messages for the server, runs it to
+// the end of the script and collects what it wrote back.
+type session struct {
+	t        *testing.T
+	input    bytes.Buffer
+	nextID   int
+	requests map[int]string
+
+	responses     map[int]json.RawMessage
+	notifications []message
+}
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func newSession(t *testing.T) *session {
+	s := &session{t: t, requests: make(map[int]string)}
+	s.request("initialize", map[string]interface{}{})
+	s.notify("initialized", map[string]interface{}{})
+	return s
+}
+
+func (s *session) send(value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	fmt.Fprintf(&s.input, "Content-Length: %v\r\n\r\n%s", len(data), data)
+}
+
+// request queues a request and returns its id.
+func (s *session) request(method string, params interface{}) int {
+	s.nextID++
+	s.requests[s.nextID] = method
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "id": s.nextID, "method": method, "params": params})
+	return s.nextID
+}
+
+func (s *session) notify(method string, params interface{}) {
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *session) open(text string) {
+	s.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: text}})
+}
+
+func (s *session) change(text string) {
+	s.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: testURI},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+	})
+}
+
+func (s *session) at(method string, line int, character int) int {
+	return s.request(method, TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: line, Character: character},
+	})
+}
+
+// run shuts the server down and serves everything queued.
+func (s *session) run() {
+	s.request("shutdown", nil)
+	s.notify("exit", nil)
+
+	var output bytes.Buffer
+	if err := NewServer(&s.input, &output).Run(); err != nil {
+		s.t.Fatalf("Run() = %v", err)
+	}
+
+	s.responses = make(map[int]json.RawMessage)
+	reader := bufio.NewReader(&output)
+	for {
+		headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		length, _ := strconv.Atoi(headers.Get("Content-Length"))
+		data := make([]byte, length)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			s.t.Fatal(err)
+		}
+
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			s.t.Fatal(err)
+		}
+		if m.ID == nil {
+			s.notifications = append(s.notifications, m)
+			continue
+		}
+		if m.Error != nil {
+			s.t.Fatalf("%v: error %+v", s.requests[*m.ID], *m.Error)
+		}
+		s.responses[*m.ID] = m.Result
+	}
+}
+
+// result decodes the response to request id into result.
+func (s *session) result(id int, result interface{}) {
+	s.t.Helper()
+	data, ok := s.responses[id]
+	if !ok {
+		s.t.Fatalf("no response to %v", s.requests[id])
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		s.t.Fatalf("%v: %v", s.requests[id], err)
+	}
+}
+
+// diagnostics returns the diagnostics in each publishDiagnostics
+// notification, in order.
+func (s *session) diagnostics() [][]Diagnostic {
+	published := make([][]Diagnostic, 0)
+	for _, notification := range s.notifications {
+		if notification.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(notification.Params, &params); err != nil {
+			s.t.Fatal(err)
+		}
+		published = append(published, params.Diagnostics)
+	}
+	return published
+}
+
+func span(line int, start int, end int) Range {
+	return Range{Start: Position{line, start}, End: Position{line, end}}
+}
+
+func TestNavigation(t *testing.T) {
+	s := newSession(t)
+	s.open(testSource)
+	definition := s.at("textDocument/definition", 3, 13)
+	references := s.request("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Position:     Position{Line: 0, Character: 5},
+		},
+	})
+	hover := s.at("textDocument/hover", 4, 7)
+	nothing := s.at("textDocument/hover", 1, 2)
+	symbols := s.request("textDocument/documentSymbol", DocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}})
+	tokens := s.request("textDocument/semanticTokens/full", DocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}})
+	s.run()
+
+	var location Location
+	s.result(definition, &location)
+	if want := (Location{URI: testURI, Range: span(0, 4, 7)}); location != want {
+		t.Errorf("definition = %+v, want %+v", location, want)
+	}
+
+	var locations []Location
+	s.result(references, &locations)
+	if want := []Location{{URI: testURI, Range: span(3, 12, 15)}}; !reflect.DeepEqual(locations, want) {
+		t.Errorf("references = %+v, want %+v", locations, want)
+	}
+
+	var hovered Hover
+	s.result(hover, &hovered)
+	want := Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```lox\nvar total = add(1, 2);\n```"},
+		Range:    span(4, 6, 11),
+	}
+	if hovered != want {
+		t.Errorf("hover = %+v, want %+v", hovered, want)
+	}
+	if result := s.responses[nothing]; string(result) != "null" {
+		t.Errorf("hover on a keyword = %s, want null", result)
+	}
+
+	var documentSymbols []DocumentSymbol
+	s.result(symbols, &documentSymbols)
+	wantSymbols := []DocumentSymbol{
+		{Name: "add", Kind: SymbolKindFunction, Range: span(0, 4, 7), SelectionRange: span(0, 4, 7)},
+		{Name: "total", Kind: SymbolKindVariable, Range: span(3, 4, 9), SelectionRange: span(3, 4, 9)},
+	}
+	if !reflect.DeepEqual(documentSymbols, wantSymbols) {
+		t.Errorf("documentSymbol = %+v, want %+v", documentSymbols, wantSymbols)
+	}
+
+	// Each token is five numbers: line and start relative to the previous
+	// token, length, type and modifiers.
+	var semantic SemanticTokens
+	s.result(tokens, &semantic)
+	firstLine := []int{
+		0, 0, 3, 0, 0, // fun
+		0, 4, 3, 2, modifierDeclaration, // add
+		0, 4, 1, 1, modifierDeclaration, // a
+		0, 3, 1, 1, modifierDeclaration, // b
+	}
+	if len(semantic.Data)%5 != 0 || len(semantic.Data) < len(firstLine) || !reflect.DeepEqual(semantic.Data[:len(firstLine)], firstLine) {
+		t.Errorf("semanticTokens = %v, want it to start %v", semantic.Data, firstLine)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	s := newSession(t)
+	s.open("var a = ;\n")
+	s.change("{ var unused = 1; }\n")
+	s.change(testSource)
+	s.run()
+
+	want := [][]Diagnostic{
+		{{Range: span(0, 8, 9), Severity: SeverityError, Source: "go-lox", Message: "Expect expression."}},
+		{{Range: span(0, 6, 6), Severity: SeverityWarning, Code: "unused", Source: "go-lox lint", Message: "'unused' is declared but never used."}},
+		{},
+	}
+	if got := s.diagnostics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSymbolsSurviveSyntaxErrors(t *testing.T) {
+	s := newSession(t)
+	s.open(testSource)
+	s.change(testSource + "print total +\n")
+	definition := s.at("textDocument/definition", 4, 7)
+	hover := s.at("textDocument/hover", 3, 13)
+	s.run()
+
+	if published := s.diagnostics(); len(published) != 2 || len(published[1]) != 1 {
+		t.Fatalf("diagnostics = %+v, want one syntax error after the change", published)
+	}
+	var location Location
+	s.result(definition, &location)
+	if want := (Location{URI: testURI, Range: span(3, 4, 9)}); location != want {
+		t.Errorf("definition = %+v, want %+v", location, want)
+	}
+	var hovered Hover
+	s.result(hover, &hovered)
+	if hovered.Range != span(3, 12, 15) {
+		t.Errorf("hover = %+v, want the call to add", hovered)
+	}
+}
@@ -1,19 +1,131 @@
 package main
 
 import (
-	"github.com/rdtharri/go-lox/runner"
+	"flag"
+	"fmt"
 	"os"
+	"strings"
+
+	"github.com/rdtharri/go-lox/runner"
 )
 
+// fsRoots collects -allow-fs directories, which may be repeated or given as a
+// comma-separated list.
+type fsRoots struct {
+	capabilities *runner.Capabilities
+}
+
+func (f fsRoots) String() string {
+	if f.capabilities == nil {
+		return ""
+	}
+	return strings.Join(f.capabilities.FSRoots, ",")
+}
+
+func (f fsRoots) Set(value string) error {
+	f.capabilities.FS = true
+	for _, root := range strings.Split(value, ",") {
+		if root != "" {
+			f.capabilities.FSRoots = append(f.capabilities.FSRoots, root)
+		}
+	}
+	return nil
+}
+
+// commands are the subcommands that may be given as the first argument.
+var commands = map[string]func(args []string) int{
+	"ast":    astCommand,
+	"dap":    dapCommand,
+	"fmt":    fmtCommand,
+	"lint":   lintCommand,
+	"lsp":    lspCommand,
+	"test":   testCommand,
+	"tokens": tokensCommand,
+}
+
 func main() {
-	runner := runner.LoxRunner{}
-	args := os.Args[1:]
-	if len(args) == 1 {
-		runner.RunFile(args[0])
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
+	lox := runner.LoxRunner{}
+	flag.IntVar(&lox.Limits.MaxSteps, "max-steps", 0, "stop after this many evaluation steps (0 for no limit)")
+	flag.IntVar(&lox.Limits.MaxDepth, "max-depth", 0, "maximum nesting depth (0 for the default)")
+	flag.DurationVar(&lox.Limits.Timeout, "timeout", 0, "wall time allowed for a script (0 for no limit)")
+	flag.BoolVar(&lox.Strict, "strict", false, "make dividing by zero a runtime error")
+
+	flag.Var(fsRoots{&lox.Capabilities}, "allow-fs", "allow file access beneath these comma-separated directories")
+	flag.BoolVar(&lox.Capabilities.Env, "allow-env", false, "allow access to environment variables")
+	flag.BoolVar(&lox.Capabilities.Exec, "allow-exec", false, "reserved for natives that run subprocesses; none do yet")
+	flag.BoolVar(&lox.Capabilities.Net, "allow-net", false, "reserved for natives that use the network; none do yet")
+	allowAll := flag.Bool("allow-all", false, "allow every capability")
+
+	profile := flag.String("profile", "", "profile the script, writing the report to this file (- for stderr)")
+	profileFormat := flag.String("profile-format", "text", "profile report format: text or pprof")
+	coverage := flag.String("coverage", "", "record coverage, writing lcov.info and index.html to this directory")
+	trace := flag.String("trace", "", "log each statement, definition, assignment, call and runtime error to this file (- for stderr)")
+	traceFormat := flag.String("trace-format", "text", "trace format: text or json")
+	flag.Parse()
+
+	if *allowAll {
+		lox.Capabilities = runner.AllCapabilities()
+	}
+	if *profile != "" {
+		if *profileFormat != "text" && *profileFormat != "pprof" {
+			fmt.Fprintf(os.Stderr, "unknown profile format %q\n", *profileFormat)
+			os.Exit(64)
+		}
+		lox.Profiler = runner.NewProfiler()
+	}
+
+	closeTrace := func() error { return nil }
+	if *trace != "" {
+		tracer, close, err := newTracer(*trace, *traceFormat)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(64)
+		}
+		lox.Tracer, closeTrace = tracer, close
+	}
+
+	// Arguments after the script are passed to it as args.
+	args := flag.Args()
+	script := ""
+	if len(args) > 0 {
+		script, lox.Args = args[0], args[1:]
+	}
+	if *coverage != "" {
+		lox.Coverage = runner.NewCoverage(script)
+	}
+
+	if script != "" {
+		lox.RunFile(script)
 	} else {
-		runner.RunPrompt()
+		lox.RunPrompt()
+	}
+
+	if lox.Profiler != nil {
+		if err := writeProfile(lox.Profiler, *profile, *profileFormat, script); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
-	if runner.HadError {
+	if lox.Coverage != nil {
+		if err := writeCoverage(lox.Coverage, *coverage, script); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if err := closeTrace(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if lox.Exited {
+		os.Exit(lox.ExitCode)
+	}
+	if lox.HadError {
 		os.Exit(65)
 	}
+	if lox.HadRuntimeError {
+		os.Exit(70)
+	}
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+// writeProfile writes profiler's report to path, or to stderr when path is
+// "-". script is the profiled file, if there was one.
+func writeProfile(profiler *runner.Profiler, path string, format string, script string) error {
+	var out io.Writer = os.Stderr
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch format {
+	case "pprof":
+		return profiler.WritePprof(out, script)
+	case "text":
+		source := ""
+		if script != "" {
+			data, err := os.ReadFile(script)
+			if err != nil {
+				return err
+			}
+			source = string(data)
+		}
+		return profiler.WriteText(out, source)
+	}
+	return fmt.Errorf("unknown profile format %q", format)
+}
+
+// writeCoverage writes coverage as lcov.info and index.html in dir.
+func writeCoverage(coverage *runner.Coverage, dir string, script string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	lcov, err := os.Create(filepath.Join(dir, "lcov.info"))
+	if err != nil {
+		return err
+	}
+	defer lcov.Close()
+	if err := coverage.WriteLCOV(lcov); err != nil {
+		return err
+	}
+
+	source := ""
+	if script != "" {
+		data, err := os.ReadFile(script)
+		if err != nil {
+			return err
+		}
+		source = string(data)
+	}
+	html, err := os.Create(filepath.Join(dir, "index.html"))
+	if err != nil {
+		return err
+	}
+	defer html.Close()
+	return coverage.WriteHTML(html, source)
+}
+
+// writeTestCoverage writes the coverage of each test file to dir: all of it
+// in lcov.info, and a page per file named after the file's path.
+func writeTestCoverage(coverages []*runner.Coverage, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	lcov, err := os.Create(filepath.Join(dir, "lcov.info"))
+	if err != nil {
+		return err
+	}
+	defer lcov.Close()
+	for _, coverage := range coverages {
+		if err := coverage.WriteLCOV(lcov); err != nil {
+			return err
+		}
+
+		source, err := os.ReadFile(coverage.File)
+		if err != nil {
+			return err
+		}
+		path := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(coverage.File)), "/")
+		name := strings.ReplaceAll(path, "/", "_") + ".html"
+		html, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		err = coverage.WriteHTML(html, string(source))
+		if closeErr := html.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newTracer opens path, or stderr when path is "-", for a trace in format.
+// The returned function closes the file once the program has finished.
+func newTracer(path string, format string) (*runner.Tracer, func() error, error) {
+	var traceFormat runner.TraceFormat
+	switch format {
+	case "text":
+		traceFormat = runner.TraceText
+	case "json":
+		traceFormat = runner.TraceJSON
+	default:
+		return nil, nil, fmt.Errorf("unknown trace format %q", format)
+	}
+
+	if path == "-" {
+		return runner.NewTracer(os.Stderr, traceFormat), func() error { return nil }, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return runner.NewTracer(file, traceFormat), file.Close, nil
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrAssertion marks the RuntimeError raised by a failed assertion, so test
+// runners can tell failures apart from other errors.
+var ErrAssertion = errors.New("assertion failed")
+
+func init() {
+	Natives.Register(&NativeFunction{
+		Name:   "assert",
+		Params: -1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if err := checkArgumentCount("assert", arguments, 1, 2); err != nil {
+				return nil, err
+			}
+			if !interpreter.isTruthy(arguments[0]) {
+				return nil, assertionError(arguments[1:], "Assertion failed.")
+			}
+			return nil, nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "assertEqual",
+		Params: -1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if err := checkArgumentCount("assertEqual", arguments, 2, 3); err != nil {
+				return nil, err
+			}
+			actual, expected := arguments[0], arguments[1]
+			if !interpreter.isEqual(actual, expected) {
+				return nil, assertionError(arguments[2:], "Expected %v but got %v.", describe(expected), describe(actual))
+			}
+			return nil, nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "assertThrows",
+		Params: -1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if err := checkArgumentCount("assertThrows", arguments, 1, 2); err != nil {
+				return nil, err
+			}
+			function, ok := arguments[0].(LoxCallable)
+			if !ok || function.Arity() > 0 {
+				return nil, &RuntimeError{Message: "assertThrows expects a function with no parameters."}
+			}
+
+			err := interpreter.catch(func() {
+				function.Call(interpreter, Token{}, nil)
+			})
+			if err == nil {
+				return nil, assertionError(arguments[1:], "Expected an error but none was raised.")
+			}
+			return err.Message, nil
+		},
+	})
+}
+
+// catch runs body, returning the RuntimeError it raises. Errors from
+// exceeding the interpreter's limits or calling exit aren't caught, since
+// the program has to stop for those.
+func (i *Interpreter) catch(body func()) (caught *RuntimeError) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(*RuntimeError)
+			if !ok || isLimitError(err) || isExit(err) {
+				panic(r)
+			}
+			caught = err
+		}
+	}()
+	body()
+	return nil
+}
+
+func isLimitError(err error) bool {
+	return errors.Is(err, ErrStepLimit) ||
+		errors.Is(err, ErrDepthLimit) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func checkArgumentCount(name string, arguments []interface{}, min int, max int) error {
+	if len(arguments) < min || len(arguments) > max {
+		return &RuntimeError{Message: fmt.Sprintf("Expected %v to %v arguments to %v but got %v.", min, max, name, len(arguments))}
+	}
+	return nil
+}
+
+// assertionError builds the error for a failed assertion, preferring the
+// caller's message when one was passed.
+func assertionError(message []interface{}, format string, args ...interface{}) error {
+	text := fmt.Sprintf(format, args...)
+	if len(message) > 0 {
+		text = Stringify(message[0])
+	}
+	return &RuntimeError{Message: text, Err: ErrAssertion}
+}
+
+// describe formats a value for an assertion message, quoting strings so
+// that "1" and 1 can be told apart.
+func describe(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return fmt.Sprintf("%q", text)
+	}
+	return Stringify(value)
+}
@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AstPrinter renders statements and expressions as parenthesized,
+// Lisp-style trees. Nested statements are indented two spaces per level.
+type AstPrinter struct {
+	result string
+}
+
+func (a *AstPrinter) Print(stmts []Statement) string {
+	lines := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		lines = append(lines, a.PrintStatement(stmt))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *AstPrinter) PrintStatement(stmt Statement) string {
+	stmt.Accept(a)
+	return a.result
+}
+
+func (a *AstPrinter) PrintExpression(expr Expression) string {
+	return expr.Accept(a).(string)
+}
+
+func (a *AstPrinter) VisitExpressionStatement(es *ExpressionStatement) {
+	a.result = a.parenthesize(";", es.Expression)
+}
+
+func (a *AstPrinter) VisitPrintStatement(ps *PrintStatement) {
+	a.result = a.parenthesize("print", ps.Expression)
+}
+
+func (a *AstPrinter) VisitVarStatement(vs *VarStatement) {
+	if vs.Initializer == nil {
+		a.result = "(var " + vs.Name.Lexeme + ")"
+		return
+	}
+	a.result = a.parenthesize("var "+vs.Name.Lexeme, vs.Initializer)
+}
+
+func (a *AstPrinter) VisitBlockStatement(bs *BlockStatement) {
+	var builder strings.Builder
+	builder.WriteString("(block")
+	for _, stmt := range bs.Statements {
+		builder.WriteString("\n")
+		builder.WriteString(indent(a.PrintStatement(stmt)))
+	}
+	builder.WriteString(")")
+	a.result = builder.String()
+}
+
+func (a *AstPrinter) VisitIfStatement(is *IfStatement) {
+	var builder strings.Builder
+	builder.WriteString("(if ")
+	builder.WriteString(a.PrintExpression(is.Condition))
+	builder.WriteString("\n")
+	builder.WriteString(indent(a.PrintStatement(is.ThenBranch)))
+	if is.ElseBranch != nil {
+		builder.WriteString("\n")
+		builder.WriteString(indent(a.PrintStatement(is.ElseBranch)))
+	}
+	builder.WriteString(")")
+	a.result = builder.String()
+}
+
+func (a *AstPrinter) VisitFunctionStatement(fs *FunctionStatement) {
+	params := make([]string, 0, len(fs.Params))
+	for _, param := range fs.Params {
+		params = append(params, param.Lexeme)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("(fun " + fs.Name.Lexeme + " (" + strings.Join(params, " ") + ")")
+	for _, stmt := range fs.Body.Statements {
+		builder.WriteString("\n")
+		builder.WriteString(indent(a.PrintStatement(stmt)))
+	}
+	builder.WriteString(")")
+	a.result = builder.String()
+}
+
+func (a *AstPrinter) VisitReturnStatement(rs *ReturnStatement) {
+	if rs.Value == nil {
+		a.result = "(return)"
+		return
+	}
+	a.result = a.parenthesize("return", rs.Value)
+}
+
+func (a *AstPrinter) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	return a.parenthesize(be.Operator.Lexeme, be.Left, be.Right)
+}
+
+func (a *AstPrinter) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	return a.parenthesize("group", ge.Expression)
+}
+
+func (a *AstPrinter) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	switch value := le.Token.Value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return "\"" + value + "\""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func (a *AstPrinter) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	return a.parenthesize(ue.Operator.Lexeme, ue.Right)
+}
+
+func (a *AstPrinter) VisitVarExpression(ve *VarExpression) interface{} {
+	return ve.Name.Lexeme
+}
+
+func (a *AstPrinter) VisitAssignExpression(ae *AssignExpression) interface{} {
+	return a.parenthesize("= "+ae.Name.Lexeme, ae.Value)
+}
+
+func (a *AstPrinter) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	return a.parenthesize(le.Operator.Lexeme, le.Left, le.Right)
+}
+
+func (a *AstPrinter) VisitCallExpression(ce *CallExpression) interface{} {
+	return a.parenthesize("call", append([]Expression{ce.Callee}, ce.Arguments...)...)
+}
+
+func (a *AstPrinter) VisitListExpression(le *ListExpression) interface{} {
+	return a.parenthesize("list", le.Elements...)
+}
+
+func (a *AstPrinter) VisitIndexExpression(ie *IndexExpression) interface{} {
+	return a.parenthesize("index", ie.Object, ie.Index)
+}
+
+func (a *AstPrinter) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	return a.parenthesize("index=", se.Object, se.Index, se.Value)
+}
+
+func (a *AstPrinter) VisitMapExpression(me *MapExpression) interface{} {
+	entries := make([]Expression, 0, 2*len(me.Keys))
+	for index, key := range me.Keys {
+		entries = append(entries, key, me.Values[index])
+	}
+	return a.parenthesize("map", entries...)
+}
+
+func (a *AstPrinter) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	var builder strings.Builder
+	builder.WriteString("(interpolate")
+	for index, part := range ie.Strings {
+		text, _ := part.Value.(string)
+		builder.WriteString(" \"" + text + "\"")
+		if index < len(ie.Expressions) {
+			builder.WriteString(" " + a.PrintExpression(ie.Expressions[index]))
+		}
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func (a *AstPrinter) VisitGetExpression(ge *GetExpression) interface{} {
+	return a.parenthesize("."+ge.Name.Lexeme, ge.Object)
+}
+
+func (a *AstPrinter) parenthesize(name string, exprs ...Expression) string {
+	var builder strings.Builder
+	builder.WriteString("(")
+	builder.WriteString(name)
+	for _, expr := range exprs {
+		builder.WriteString(" ")
+		builder.WriteString(a.PrintExpression(expr))
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
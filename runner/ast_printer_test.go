@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"io"
+	"testing"
+)
+
+func TestPrintExpression(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"-a * b + c", "(+ (* (- a) b) c)"},
+		{"a or b and c", "(or a (and b c))"},
+		{"a and b or c", "(or (and a b) c)"},
+		{"1 + 2 * 3 - 4", "(- (+ 1 (* 2 3)) 4)"},
+		{"!a == b", "(== (! a) b)"},
+		{"a < b == c >= d", "(== (< a b) (>= c d))"},
+		{"a = b = c", "(= a (= b c))"},
+		{"(1 + 2) * 3", "(* (group (+ 1 2)) 3)"},
+		{"f(1)(2)", "(call (call f 1) 2)"},
+		{"-2 ** 2", "(- (** 2 2))"},
+		{"2 ** 3 ** 2", "(** 2 (** 3 2))"},
+	}
+	for _, test := range tests {
+		lox := LoxRunner{ErrorOutput: io.Discard}
+		expr := lox.ParseExpression(test.source)
+		if lox.HadError {
+			t.Errorf("%q doesn't parse: %v", test.source, lox.Errors)
+			continue
+		}
+		printer := AstPrinter{}
+		if got := printer.PrintExpression(expr); got != test.want {
+			t.Errorf("PrintExpression(%q) = %v, want %v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestPrintStatements(t *testing.T) {
+	source := `if (a) { print 1; } else if (b) print 2;
+{ var x = 1; { print x; } }
+fun f(a) { return a; }
+`
+	want := `(if a
+  (block
+    (print 1))
+  (if b
+    (print 2)))
+(block
+  (var x 1)
+  (block
+    (print x)))
+(fun f (a)
+  (return a))`
+
+	lox := LoxRunner{ErrorOutput: io.Discard}
+	stmts := lox.Parse(source)
+	if lox.HadError {
+		t.Fatalf("source doesn't parse: %v", lox.Errors)
+	}
+	printer := AstPrinter{}
+	if got := printer.Print(stmts); got != want {
+		t.Errorf("Print =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package runner
+
+import "fmt"
+
+// LoxCallable is anything that can appear before a call's parentheses.
+// Arity returns -1 for callables that check their own argument count.
+type LoxCallable interface {
+	Arity() int
+	Call(interpreter *Interpreter, paren Token, arguments []interface{}) interface{}
+}
+
+// NativeFunction is a callable implemented in Go. Fn reports failures by
+// returning an error, which is raised as a RuntimeError at the call site.
+type NativeFunction struct {
+	Name     string
+	Params   int
+	Requires Capability
+	Fn       func(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
+}
+
+func (n *NativeFunction) Arity() int {
+	return n.Params
+}
+
+func (n *NativeFunction) Call(interpreter *Interpreter, paren Token, arguments []interface{}) interface{} {
+	if !interpreter.capabilities.allows(n.Requires) {
+		panic(&RuntimeError{
+			Line:    paren.Line,
+			Message: fmt.Sprintf("Permission denied: '%v' requires %v access.", n.Name, n.Requires),
+			Err:     ErrPermissionDenied,
+		})
+	}
+
+	value, err := n.Fn(interpreter, arguments)
+	if err != nil {
+		if runtimeErr, ok := err.(*RuntimeError); ok {
+			if runtimeErr.Line == 0 {
+				runtimeErr.Line = paren.Line
+			}
+			panic(runtimeErr)
+		}
+		panic(&RuntimeError{Line: paren.Line, Message: err.Error(), Err: err})
+	}
+	return value
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// LoxFunction is a function declared in a program. Closure is the
+// environment it was declared in.
+type LoxFunction struct {
+	Declaration *FunctionStatement
+	Closure     *Environment
+}
+
+// returnValue unwinds a function call from a return statement.
+type returnValue struct {
+	value interface{}
+}
+
+func (f *LoxFunction) Arity() int {
+	return len(f.Declaration.Params)
+}
+
+func (f *LoxFunction) Call(interpreter *Interpreter, paren Token, arguments []interface{}) (result interface{}) {
+	env := NewEnvironment(f.Closure)
+	for index, param := range f.Declaration.Params {
+		if interpreter.Tracer != nil {
+			interpreter.Tracer.define(interpreter, param.Lexeme, arguments[index])
+		}
+		env.Define(param.Lexeme, arguments[index])
+	}
+
+	if interpreter.Coverage != nil {
+		interpreter.Coverage.call(f.Declaration)
+	}
+	interpreter.pushFrame(f.Declaration.Name.Lexeme)
+	defer interpreter.popFrame()
+	defer func() {
+		if r := recover(); r != nil {
+			returned, ok := r.(returnValue)
+			if !ok {
+				panic(r)
+			}
+			result = returned.value
+		}
+	}()
+
+	interpreter.executeBlock(f.Declaration.Body.Statements, env)
+	return nil
+}
+
+func (f *LoxFunction) String() string {
+	return "<fn " + f.Declaration.Name.Lexeme + ">"
+}
@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Capability names a class of host resource that a native may touch.
+type Capability int
+
+const (
+	CapNone Capability = iota
+	CapFS
+	CapEnv
+	// CapExec and CapNet are reserved for natives that run subprocesses or
+	// use the network. No built-in native requires them yet.
+	CapExec
+	CapNet
+)
+
+func (c Capability) String() string {
+	switch c {
+	case CapFS:
+		return "fs"
+	case CapEnv:
+		return "env"
+	case CapExec:
+		return "exec"
+	case CapNet:
+		return "net"
+	}
+	return "no"
+}
+
+// Capabilities is the set of host resources scripts run by an interpreter are
+// allowed to reach. The zero value allows nothing, so untrusted scripts are
+// sandboxed unless the embedder opts in.
+type Capabilities struct {
+	FS bool
+	// FSRoots limits file access to paths beneath these directories. An
+	// empty list allows any path once FS is granted.
+	FSRoots []string
+	Env     bool
+	Exec    bool
+	Net     bool
+}
+
+// AllCapabilities grants every capability with no path restrictions.
+func AllCapabilities() Capabilities {
+	return Capabilities{FS: true, Env: true, Exec: true, Net: true}
+}
+
+func (c Capabilities) allows(capability Capability) bool {
+	switch capability {
+	case CapNone:
+		return true
+	case CapFS:
+		return c.FS
+	case CapEnv:
+		return c.Env
+	case CapExec:
+		return c.Exec
+	case CapNet:
+		return c.Net
+	}
+	return false
+}
+
+// allowsPath reports whether path may be touched by file natives, and
+// returns the path to use for it. Inside a sandbox that's the resolved path,
+// so a symlink swapped in after the check can't redirect the access.
+func (c Capabilities) allowsPath(path string) (string, bool) {
+	if !c.FS {
+		return "", false
+	}
+	if len(c.FSRoots) == 0 {
+		return path, true
+	}
+
+	target, err := resolvePath(path)
+	if err != nil {
+		return "", false
+	}
+	for _, root := range c.FSRoots {
+		base, err := resolvePath(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(base, target)
+		if err != nil {
+			continue
+		}
+		if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// maxSymlinks bounds how many links resolvePath follows, like the kernel's
+// ELOOP limit.
+const maxSymlinks = 255
+
+// resolvePath makes path absolute and resolves symlinks in as much of it as
+// exists, so files that are about to be created can still be checked. It
+// walks the path a component at a time rather than cleaning it first: the
+// ".." in "link/../file" is relative to where link points, not to link.
+func resolvePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		path = dir + string(filepath.Separator) + path
+	}
+
+	resolved := filepath.VolumeName(path) + string(filepath.Separator)
+	rest := strings.Split(path[len(filepath.VolumeName(path)):], string(filepath.Separator))
+	links := 0
+	missing := false
+	for len(rest) > 0 {
+		part := rest[0]
+		rest = rest[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		if missing {
+			resolved = next
+			continue
+		}
+		info, err := os.Lstat(next)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Nothing below a missing directory can be a link.
+			missing = true
+			resolved = next
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: "resolve", Path: path, Err: syscall.ELOOP}
+		}
+		link, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(link) {
+			resolved = filepath.VolumeName(link) + string(filepath.Separator)
+			link = link[len(filepath.VolumeName(link)):]
+		}
+		rest = append(strings.Split(link, string(filepath.Separator)), rest...)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// registerNeedsEnv registers testNeedsEnv, a native requiring env access,
+// for the rest of the test.
+func registerNeedsEnv(t *testing.T) {
+	Natives.Register(&NativeFunction{
+		Name:     "testNeedsEnv",
+		Params:   0,
+		Requires: CapEnv,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return "granted", nil
+		},
+	})
+	t.Cleanup(func() { delete(Natives.natives, "testNeedsEnv") })
+}
+
+func TestPermissionDenied(t *testing.T) {
+	registerNeedsEnv(t)
+	r := LoxRunner{Output: io.Discard, ErrorOutput: io.Discard}
+	err := r.Run(context.Background(), "print 1;\ntestNeedsEnv();\n")
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("error = %v, want ErrPermissionDenied", err)
+	}
+	var runtimeError *RuntimeError
+	errors.As(err, &runtimeError)
+	want := RuntimeError{Line: 2, Message: "Permission denied: 'testNeedsEnv' requires env access.", Err: ErrPermissionDenied}
+	if *runtimeError != want {
+		t.Errorf("error = %#v, want %#v", *runtimeError, want)
+	}
+
+	// Other capabilities don't grant env access.
+	r.Capabilities = Capabilities{FS: true, Exec: true, Net: true}
+	if err := r.Run(context.Background(), "testNeedsEnv();"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("with every capability but env, error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestPermissionGranted(t *testing.T) {
+	registerNeedsEnv(t)
+	for _, capabilities := range []Capabilities{{Env: true}, AllCapabilities()} {
+		r := LoxRunner{Output: io.Discard, Capabilities: capabilities}
+		if err := r.Run(context.Background(), "testNeedsEnv();"); err != nil {
+			t.Errorf("with %+v, error = %v", capabilities, err)
+		}
+	}
+}
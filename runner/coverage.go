@@ -0,0 +1,269 @@
+package runner
+
+import "sort"
+
+// Coverage records which statements, branches and functions of a program
+// run. Set one on a LoxRunner before running a program, then write a report
+// with WriteLCOV or WriteHTML.
+//
+// Branches are the two arms of every if, whether or not it has an else, and
+// the two outcomes of every 'and' and 'or': stopping at the left operand or
+// going on to evaluate the right.
+type Coverage struct {
+	// File names the program in reports.
+	File string
+
+	statements map[Statement]*CoveredStatement
+	branches   map[interface{}]*CoveredBranch
+	functions  map[*FunctionStatement]*CoveredFunction
+}
+
+type CoveredStatement struct {
+	Line  int
+	Count int
+}
+
+// CoveredBranch is one branch point. Taken counts how often each branch ran.
+type CoveredBranch struct {
+	Line   int
+	Column int
+	Taken  [2]int
+}
+
+// Evaluated reports whether execution reached the branch point at all.
+func (b *CoveredBranch) Evaluated() bool {
+	return b.Taken[0]+b.Taken[1] > 0
+}
+
+type CoveredFunction struct {
+	Name  string
+	Line  int
+	Calls int
+}
+
+func NewCoverage(file string) *Coverage {
+	return &Coverage{
+		File:       file,
+		statements: make(map[Statement]*CoveredStatement),
+		branches:   make(map[interface{}]*CoveredBranch),
+		functions:  make(map[*FunctionStatement]*CoveredFunction),
+	}
+}
+
+// add registers everything in stmts, so that what never runs is reported
+// too.
+func (c *Coverage) add(stmts []Statement) {
+	indexer := coverageIndexer{coverage: c}
+	for _, stmt := range stmts {
+		indexer.statement(stmt)
+	}
+}
+
+func (c *Coverage) statement(stmt Statement) {
+	if covered, ok := c.statements[stmt]; ok {
+		covered.Count++
+	}
+}
+
+func (c *Coverage) branch(node interface{}, taken int) {
+	if covered, ok := c.branches[node]; ok {
+		covered.Taken[taken]++
+	}
+}
+
+func (c *Coverage) call(declaration *FunctionStatement) {
+	if covered, ok := c.functions[declaration]; ok {
+		covered.Calls++
+	}
+}
+
+// Statements returns every statement in source order.
+func (c *Coverage) Statements() []*CoveredStatement {
+	statements := make([]*CoveredStatement, 0, len(c.statements))
+	for _, statement := range c.statements {
+		statements = append(statements, statement)
+	}
+	sort.SliceStable(statements, func(a, b int) bool {
+		return statements[a].Line < statements[b].Line
+	})
+	return statements
+}
+
+// Branches returns every branch point in source order.
+func (c *Coverage) Branches() []*CoveredBranch {
+	branches := make([]*CoveredBranch, 0, len(c.branches))
+	for _, branch := range c.branches {
+		branches = append(branches, branch)
+	}
+	sort.Slice(branches, func(a, b int) bool {
+		if branches[a].Line != branches[b].Line {
+			return branches[a].Line < branches[b].Line
+		}
+		return branches[a].Column < branches[b].Column
+	})
+	return branches
+}
+
+// Functions returns every declared function in source order.
+func (c *Coverage) Functions() []*CoveredFunction {
+	functions := make([]*CoveredFunction, 0, len(c.functions))
+	for _, function := range c.functions {
+		functions = append(functions, function)
+	}
+	sort.SliceStable(functions, func(a, b int) bool {
+		return functions[a].Line < functions[b].Line
+	})
+	return functions
+}
+
+// Lines returns how often each line with a statement on it ran, taken as the
+// most any statement on the line ran.
+func (c *Coverage) Lines() map[int]int {
+	lines := make(map[int]int)
+	for _, statement := range c.statements {
+		if count, ok := lines[statement.Line]; !ok || statement.Count > count {
+			lines[statement.Line] = statement.Count
+		}
+	}
+	return lines
+}
+
+// coverageIndexer walks a program registering its statements, branch points
+// and functions.
+type coverageIndexer struct {
+	coverage *Coverage
+}
+
+func (x *coverageIndexer) statement(stmt Statement) {
+	if stmt == nil {
+		return
+	}
+	x.coverage.statements[stmt] = &CoveredStatement{Line: StatementLine(stmt)}
+	stmt.Accept(x)
+}
+
+func (x *coverageIndexer) expression(expr Expression) {
+	if expr != nil {
+		expr.Accept(x)
+	}
+}
+
+func (x *coverageIndexer) VisitExpressionStatement(es *ExpressionStatement) {
+	x.expression(es.Expression)
+}
+
+func (x *coverageIndexer) VisitPrintStatement(ps *PrintStatement) {
+	x.expression(ps.Expression)
+}
+
+func (x *coverageIndexer) VisitVarStatement(vs *VarStatement) {
+	x.expression(vs.Initializer)
+}
+
+func (x *coverageIndexer) VisitBlockStatement(bs *BlockStatement) {
+	for _, stmt := range bs.Statements {
+		x.statement(stmt)
+	}
+}
+
+func (x *coverageIndexer) VisitIfStatement(is *IfStatement) {
+	x.coverage.branches[is] = &CoveredBranch{Line: is.Keyword.Line, Column: is.Keyword.Column}
+	x.expression(is.Condition)
+	x.statement(is.ThenBranch)
+	x.statement(is.ElseBranch)
+}
+
+func (x *coverageIndexer) VisitFunctionStatement(fs *FunctionStatement) {
+	x.coverage.functions[fs] = &CoveredFunction{Name: fs.Name.Lexeme, Line: fs.Name.Line}
+	for _, stmt := range fs.Body.Statements {
+		x.statement(stmt)
+	}
+}
+
+func (x *coverageIndexer) VisitReturnStatement(rs *ReturnStatement) {
+	x.expression(rs.Value)
+}
+
+func (x *coverageIndexer) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	x.expression(be.Left)
+	x.expression(be.Right)
+	return nil
+}
+
+func (x *coverageIndexer) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	x.expression(ge.Expression)
+	return nil
+}
+
+func (x *coverageIndexer) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	return nil
+}
+
+func (x *coverageIndexer) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	x.expression(ue.Right)
+	return nil
+}
+
+func (x *coverageIndexer) VisitVarExpression(ve *VarExpression) interface{} {
+	return nil
+}
+
+func (x *coverageIndexer) VisitAssignExpression(ae *AssignExpression) interface{} {
+	x.expression(ae.Value)
+	return nil
+}
+
+func (x *coverageIndexer) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	x.coverage.branches[le] = &CoveredBranch{Line: le.Operator.Line, Column: le.Operator.Column}
+	x.expression(le.Left)
+	x.expression(le.Right)
+	return nil
+}
+
+func (x *coverageIndexer) VisitCallExpression(ce *CallExpression) interface{} {
+	x.expression(ce.Callee)
+	for _, argument := range ce.Arguments {
+		x.expression(argument)
+	}
+	return nil
+}
+
+func (x *coverageIndexer) VisitListExpression(le *ListExpression) interface{} {
+	for _, element := range le.Elements {
+		x.expression(element)
+	}
+	return nil
+}
+
+func (x *coverageIndexer) VisitIndexExpression(ie *IndexExpression) interface{} {
+	x.expression(ie.Object)
+	x.expression(ie.Index)
+	return nil
+}
+
+func (x *coverageIndexer) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	x.expression(se.Object)
+	x.expression(se.Index)
+	x.expression(se.Value)
+	return nil
+}
+
+func (x *coverageIndexer) VisitMapExpression(me *MapExpression) interface{} {
+	for index, key := range me.Keys {
+		x.expression(key)
+		x.expression(me.Values[index])
+	}
+	return nil
+}
+
+func (x *coverageIndexer) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	for _, expr := range ie.Expressions {
+		x.expression(expr)
+	}
+	return nil
+}
+
+func (x *coverageIndexer) VisitGetExpression(ge *GetExpression) interface{} {
+	x.expression(ge.Object)
+	return nil
+}
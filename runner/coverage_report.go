@@ -0,0 +1,191 @@
+package runner
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CoverageSummary counts what was covered out of what there is to cover.
+type CoverageSummary struct {
+	Statements, StatementsHit int
+	Branches, BranchesHit     int
+	Functions, FunctionsHit   int
+}
+
+func (c *Coverage) Summary() CoverageSummary {
+	var summary CoverageSummary
+	for _, statement := range c.statements {
+		summary.Statements++
+		if statement.Count > 0 {
+			summary.StatementsHit++
+		}
+	}
+	for _, branch := range c.branches {
+		for _, taken := range branch.Taken {
+			summary.Branches++
+			if taken > 0 {
+				summary.BranchesHit++
+			}
+		}
+	}
+	for _, function := range c.functions {
+		summary.Functions++
+		if function.Calls > 0 {
+			summary.FunctionsHit++
+		}
+	}
+	return summary
+}
+
+// WriteLCOV writes the coverage as an LCOV tracefile, as read by genhtml and
+// most CI coverage services.
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintln(&b, "TN:")
+	fmt.Fprintf(&b, "SF:%v\n", c.File)
+
+	functions := c.Functions()
+	for _, function := range functions {
+		fmt.Fprintf(&b, "FN:%v,%v\n", function.Line, function.Name)
+	}
+	for _, function := range functions {
+		fmt.Fprintf(&b, "FNDA:%v,%v\n", function.Calls, function.Name)
+	}
+	summary := c.Summary()
+	fmt.Fprintf(&b, "FNF:%v\nFNH:%v\n", summary.Functions, summary.FunctionsHit)
+
+	for block, branch := range c.Branches() {
+		for index, taken := range branch.Taken {
+			count := "-"
+			if branch.Evaluated() {
+				count = fmt.Sprint(taken)
+			}
+			fmt.Fprintf(&b, "BRDA:%v,%v,%v,%v\n", branch.Line, block, index, count)
+		}
+	}
+	fmt.Fprintf(&b, "BRF:%v\nBRH:%v\n", summary.Branches, summary.BranchesHit)
+
+	lines := c.Lines()
+	hit := 0
+	for _, line := range sortedLines(lines) {
+		fmt.Fprintf(&b, "DA:%v,%v\n", line, lines[line])
+		if lines[line] > 0 {
+			hit++
+		}
+	}
+	fmt.Fprintf(&b, "LF:%v\nLH:%v\n", len(lines), hit)
+	fmt.Fprintln(&b, "end_of_record")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedLines(lines map[int]int) []int {
+	sorted := make([]int, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+type coverageLine struct {
+	Number int
+	Text   string
+	// Count is blank for lines without statements.
+	Count string
+	// Class is covered, partial, uncovered or blank.
+	Class    string
+	Branches string
+}
+
+// WriteHTML writes a standalone page showing source, the program's text,
+// with each line marked by whether it ran.
+func (c *Coverage) WriteHTML(w io.Writer, source string) error {
+	counts := c.Lines()
+
+	missed := make(map[int]bool)
+	for _, statement := range c.statements {
+		if statement.Count == 0 {
+			missed[statement.Line] = true
+		}
+	}
+	branches := make(map[int][]string)
+	for _, branch := range c.Branches() {
+		for _, taken := range branch.Taken {
+			if taken == 0 {
+				missed[branch.Line] = true
+			}
+		}
+		branches[branch.Line] = append(branches[branch.Line], fmt.Sprintf("%v/%v", branch.Taken[0], branch.Taken[1]))
+	}
+
+	lines := make([]coverageLine, 0)
+	for index, text := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		line := coverageLine{Number: index + 1, Text: text}
+		if count, ok := counts[line.Number]; ok {
+			line.Count = fmt.Sprint(count)
+			switch {
+			case count == 0:
+				line.Class = "uncovered"
+			case missed[line.Number]:
+				line.Class = "partial"
+			default:
+				line.Class = "covered"
+			}
+		}
+		if taken, ok := branches[line.Number]; ok {
+			line.Branches = "branches " + strings.Join(taken, " ")
+		}
+		lines = append(lines, line)
+	}
+
+	return coverageTemplate.Execute(w, struct {
+		File    string
+		Summary CoverageSummary
+		Lines   []coverageLine
+	}{c.File, c.Summary(), lines})
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Funcs(template.FuncMap{
+	"percent": func(hit int, total int) string {
+		if total == 0 {
+			return "100.0%"
+		}
+		return fmt.Sprintf("%.1f%%", 100*float64(hit)/float64(total))
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage: {{.File}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.summary td { padding: 0 1em 0 0; }
+table.source { border-collapse: collapse; font-family: monospace; white-space: pre; }
+table.source td { padding: 0 0.5em; }
+td.number, td.count { color: #888; text-align: right; }
+td.branches { color: #888; }
+tr.covered td.text { background: #dfd; }
+tr.partial td.text { background: #ffd; }
+tr.uncovered td.text { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>{{.File}}</h1>
+<table class="summary">
+{{with .Summary}}
+<tr><td>Statements</td><td>{{.StatementsHit}}/{{.Statements}}</td><td>{{percent .StatementsHit .Statements}}</td></tr>
+<tr><td>Branches</td><td>{{.BranchesHit}}/{{.Branches}}</td><td>{{percent .BranchesHit .Branches}}</td></tr>
+<tr><td>Functions</td><td>{{.FunctionsHit}}/{{.Functions}}</td><td>{{percent .FunctionsHit .Functions}}</td></tr>
+{{end}}
+</table>
+<table class="source">
+{{range .Lines}}<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="count">{{.Count}}</td><td class="text">{{.Text}}</td><td class="branches">{{.Branches}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
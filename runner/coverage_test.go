@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+const coveredSource = `fun sign(n) {
+  if (n < 0) return -1;
+  return 1;
+}
+fun unused() {
+  print "never";
+}
+print sign(2) or unused();
+`
+
+func TestCoverage(t *testing.T) {
+	coverage := NewCoverage("sign.lox")
+	r := LoxRunner{Output: io.Discard, Coverage: coverage}
+	if err := r.Run(context.Background(), coveredSource); err != nil {
+		t.Fatal(err)
+	}
+
+	want := CoverageSummary{
+		Statements: 7, StatementsHit: 5,
+		Branches: 4, BranchesHit: 2,
+		Functions: 2, FunctionsHit: 1,
+	}
+	if summary := coverage.Summary(); summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+
+	var lcov bytes.Buffer
+	if err := coverage.WriteLCOV(&lcov); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"SF:sign.lox", "FNDA:0,unused", "BRDA:2,0,0,0", "BRDA:2,0,1,1", "BRDA:8,1,0,1", "DA:6,0", "LH:5"} {
+		if !strings.Contains(lcov.String(), line+"\n") {
+			t.Errorf("LCOV is missing %q:\n%v", line, lcov.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := coverage.WriteHTML(&html, coveredSource); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<tr class="uncovered"><td class="number">6</td>`) {
+		t.Errorf("HTML doesn't mark line 6 as uncovered")
+	}
+}
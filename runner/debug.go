@@ -0,0 +1,158 @@
+package runner
+
+// Hooks let tools such as debuggers observe a running interpreter. Every
+// field is optional. Hooks run on the interpreter's goroutine, so the program
+// is paused for as long as a hook blocks.
+type Hooks struct {
+	// BeforeStatement is called before each statement executes.
+	BeforeStatement func(interpreter *Interpreter, stmt Statement)
+}
+
+// Frame is one entry in the interpreter's call stack.
+type Frame struct {
+	Name string
+	// Line is the line of the statement the frame is executing.
+	Line int
+	// Environment is the innermost environment in scope for the frame.
+	Environment *Environment
+}
+
+// StackTrace returns the call stack, innermost frame first.
+func (i *Interpreter) StackTrace() []Frame {
+	frames := make([]Frame, 0, len(i.frames))
+	environment := i.Environment
+	for index := len(i.frames) - 1; index >= 0; index-- {
+		frame := *i.frames[index]
+		if index == len(i.frames)-1 {
+			frame.Environment = environment
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// pushFrame starts a new call, remembering the caller's environment so it
+// can still be inspected while the callee runs.
+func (i *Interpreter) pushFrame(name string) {
+	if len(i.frames) > 0 {
+		i.frames[len(i.frames)-1].Environment = i.Environment
+	}
+	i.frames = append(i.frames, &Frame{Name: name})
+}
+
+func (i *Interpreter) popFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// StatementLine returns the line a statement starts on.
+func StatementLine(stmt Statement) int {
+	line := leadingLine(0)
+	stmt.Accept(&line)
+	return int(line)
+}
+
+// leadingLine finds the line of the first token in a tree without walking
+// any more of it than it needs to.
+type leadingLine int
+
+func (l *leadingLine) expression(expr Expression) {
+	expr.Accept(l)
+}
+
+func (l *leadingLine) VisitExpressionStatement(es *ExpressionStatement) {
+	l.expression(es.Expression)
+}
+
+func (l *leadingLine) VisitPrintStatement(ps *PrintStatement) {
+	*l = leadingLine(ps.Keyword.Line)
+}
+
+func (l *leadingLine) VisitVarStatement(vs *VarStatement) {
+	*l = leadingLine(vs.Name.Line)
+}
+
+func (l *leadingLine) VisitBlockStatement(bs *BlockStatement) {
+	*l = leadingLine(bs.LeftBrace.Line)
+}
+
+func (l *leadingLine) VisitIfStatement(is *IfStatement) {
+	*l = leadingLine(is.Keyword.Line)
+}
+
+func (l *leadingLine) VisitFunctionStatement(fs *FunctionStatement) {
+	*l = leadingLine(fs.Keyword.Line)
+}
+
+func (l *leadingLine) VisitReturnStatement(rs *ReturnStatement) {
+	*l = leadingLine(rs.Keyword.Line)
+}
+
+func (l *leadingLine) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	l.expression(be.Left)
+	return nil
+}
+
+func (l *leadingLine) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	l.expression(ge.Expression)
+	return nil
+}
+
+func (l *leadingLine) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	*l = leadingLine(le.Token.Line)
+	return nil
+}
+
+func (l *leadingLine) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	*l = leadingLine(ue.Operator.Line)
+	return nil
+}
+
+func (l *leadingLine) VisitVarExpression(ve *VarExpression) interface{} {
+	*l = leadingLine(ve.Name.Line)
+	return nil
+}
+
+func (l *leadingLine) VisitAssignExpression(ae *AssignExpression) interface{} {
+	*l = leadingLine(ae.Name.Line)
+	return nil
+}
+
+func (l *leadingLine) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	l.expression(le.Left)
+	return nil
+}
+
+func (l *leadingLine) VisitCallExpression(ce *CallExpression) interface{} {
+	l.expression(ce.Callee)
+	return nil
+}
+
+func (l *leadingLine) VisitListExpression(le *ListExpression) interface{} {
+	*l = leadingLine(le.Bracket.Line)
+	return nil
+}
+
+func (l *leadingLine) VisitIndexExpression(ie *IndexExpression) interface{} {
+	l.expression(ie.Object)
+	return nil
+}
+
+func (l *leadingLine) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	l.expression(se.Object)
+	return nil
+}
+
+func (l *leadingLine) VisitMapExpression(me *MapExpression) interface{} {
+	*l = leadingLine(me.Brace.Line)
+	return nil
+}
+
+func (l *leadingLine) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	*l = leadingLine(ie.Strings[0].Line)
+	return nil
+}
+
+func (l *leadingLine) VisitGetExpression(ge *GetExpression) interface{} {
+	l.expression(ge.Object)
+	return nil
+}
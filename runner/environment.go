@@ -1,18 +1,14 @@
 package runner
 
-import (
-	"errors"
-)
-
 type Environment struct {
 	Enclosing *Environment
-	Values map[string]interface{}
+	Values    map[string]interface{}
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
 	return &Environment{
 		Enclosing: enclosing,
-		Values: make(map[string]interface{},0),
+		Values:    make(map[string]interface{}, 0),
 	}
 }
 
@@ -20,9 +16,9 @@ func (e *Environment) Define(name string, value interface{}) {
 	e.Values[name] = value
 }
 
-func (e *Environment) Assign(name string, value interface{}) {
-	if _, ok := e.Values[name]; ok {
-		e.Values[name] = value
+func (e *Environment) Assign(name Token, value interface{}) {
+	if _, ok := e.Values[name.Lexeme]; ok {
+		e.Values[name.Lexeme] = value
 		return
 	}
 
@@ -31,16 +27,16 @@ func (e *Environment) Assign(name string, value interface{}) {
 		return
 	}
 
-	panic(errors.New("Undefined variable '" + name + "'."))
+	panic(newRuntimeError(name, "Undefined variable '%v'.", name.Lexeme))
 }
 
-func (e *Environment) Get(name string) interface{} {
-	val, ok := e.Values[name]
+func (e *Environment) Get(name Token) interface{} {
+	val, ok := e.Values[name.Lexeme]
 	if !ok {
 		if e.Enclosing != nil {
 			return e.Enclosing.Get(name)
 		}
-		panic(errors.New("Undefined variable '" + name + "'."))
+		panic(newRuntimeError(name, "Undefined variable '%v'.", name.Lexeme))
 	}
 	return val
 
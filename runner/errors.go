@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SyntaxError is a scan or parse error. Length is the number of characters
+// at Column the error covers.
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Length  int
+	Where   string
+	Message string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("[line %v] Error%v: %v", e.Line, e.Where, e.Message)
+}
+
+// RuntimeError is raised by the interpreter when a program fails while it is
+// running. Err holds the underlying cause when there is one, such as
+// ErrStepLimit, so callers can tell limit trips apart with errors.Is.
+type RuntimeError struct {
+	Line    int
+	Message string
+	Err     error
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%v\n[line %v]", e.Message, e.Line)
+	}
+	return e.Message
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+func newRuntimeError(token Token, format string, args ...interface{}) *RuntimeError {
+	return &RuntimeError{
+		Line:    token.Line,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// errInternal marks a RuntimeError made from a panic that wasn't an error,
+// which only a bug in the interpreter or a native raises.
+var errInternal = errors.New("internal error")
+
+// toRuntimeError converts a recovered panic value into a RuntimeError so that
+// nothing raised while interpreting escapes to the host as a Go panic.
+func toRuntimeError(r interface{}) *RuntimeError {
+	switch err := r.(type) {
+	case *RuntimeError:
+		return err
+	case returnValue:
+		return &RuntimeError{Message: "Can't return from top-level code."}
+	case error:
+		return &RuntimeError{Message: err.Error(), Err: err}
+	default:
+		return &RuntimeError{Message: fmt.Sprint(r), Err: errInternal}
+	}
+}
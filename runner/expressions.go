@@ -8,6 +8,13 @@ type ExpressionVisitor interface {
 	VisitVarExpression(*VarExpression) interface{}
 	VisitAssignExpression(*AssignExpression) interface{}
 	VisitLogicalExpression(*LogicalExpression) interface{}
+	VisitCallExpression(*CallExpression) interface{}
+	VisitListExpression(*ListExpression) interface{}
+	VisitIndexExpression(*IndexExpression) interface{}
+	VisitSetIndexExpression(*SetIndexExpression) interface{}
+	VisitMapExpression(*MapExpression) interface{}
+	VisitInterpolationExpression(*InterpolationExpression) interface{}
+	VisitGetExpression(*GetExpression) interface{}
 }
 
 type Expression interface {
@@ -75,3 +82,80 @@ type LogicalExpression struct {
 func (le *LogicalExpression) Accept(v ExpressionVisitor) interface{} {
 	return v.VisitLogicalExpression(le)
 }
+
+type CallExpression struct {
+	Callee    Expression
+	Paren     Token
+	Arguments []Expression
+}
+
+func (ce *CallExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitCallExpression(ce)
+}
+
+// ListExpression is a list literal. Bracket is its opening bracket.
+type ListExpression struct {
+	Bracket  Token
+	Elements []Expression
+}
+
+func (le *ListExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitListExpression(le)
+}
+
+// IndexExpression reads object[index]. Bracket is the closing bracket.
+type IndexExpression struct {
+	Object  Expression
+	Bracket Token
+	Index   Expression
+}
+
+func (ie *IndexExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitIndexExpression(ie)
+}
+
+// SetIndexExpression assigns object[index] = value.
+type SetIndexExpression struct {
+	Object  Expression
+	Bracket Token
+	Index   Expression
+	Value   Expression
+}
+
+func (se *SetIndexExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitSetIndexExpression(se)
+}
+
+// MapExpression is a map literal. Brace is its opening brace, and Keys and
+// Values hold the entries in source order.
+type MapExpression struct {
+	Brace  Token
+	Keys   []Expression
+	Values []Expression
+}
+
+func (me *MapExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitMapExpression(me)
+}
+
+// InterpolationExpression is a string literal with expressions in it. The
+// literal text is split around the expressions, so there is always one
+// more of Strings than of Expressions.
+type InterpolationExpression struct {
+	Strings     []Token
+	Expressions []Expression
+}
+
+func (ie *InterpolationExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitInterpolationExpression(ie)
+}
+
+// GetExpression reads object.name, a map's value at the string key name.
+type GetExpression struct {
+	Object Expression
+	Name   Token
+}
+
+func (ge *GetExpression) Accept(v ExpressionVisitor) interface{} {
+	return v.VisitGetExpression(ge)
+}
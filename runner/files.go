@@ -0,0 +1,284 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoxFile is an open file, returned by open(path, mode). Programs use it
+// through its methods:
+//
+//	file.readLine()   the next line without its line ending, or nil at the end
+//	file.write(text)  writes text
+//	file.close()
+type LoxFile struct {
+	Path string
+
+	file   *os.File
+	reader *bufio.Reader
+	closed bool
+}
+
+// LoxObject is a value other than a map with properties a program can read,
+// such as a file's methods.
+type LoxObject interface {
+	Property(name string) (interface{}, bool)
+}
+
+func (f *LoxFile) String() string {
+	return "<file " + f.Path + ">"
+}
+
+func (f *LoxFile) Property(name string) (interface{}, bool) {
+	method, ok := fileMethods[name]
+	if !ok {
+		return nil, false
+	}
+	return &NativeFunction{
+		Name:     "file." + name,
+		Params:   method.params,
+		Requires: CapFS,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if f.closed {
+				return nil, &RuntimeError{Message: fmt.Sprintf("File '%v' is closed.", f.Path), Err: fs.ErrClosed}
+			}
+			return method.fn(interpreter, f, arguments)
+		},
+	}, true
+}
+
+type fileMethod struct {
+	params int
+	fn     func(interpreter *Interpreter, f *LoxFile, arguments []interface{}) (interface{}, error)
+}
+
+var fileMethods = map[string]fileMethod{
+	"readLine": {0, func(interpreter *Interpreter, f *LoxFile, arguments []interface{}) (interface{}, error) {
+		if f.reader == nil {
+			f.reader = bufio.NewReader(f.file)
+		}
+		line, err := f.reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, fileError("file.readLine", f.Path, err)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+	}},
+	"write": {1, func(interpreter *Interpreter, f *LoxFile, arguments []interface{}) (interface{}, error) {
+		text, err := argument[string]("file.write", arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.file.WriteString(text); err != nil {
+			return nil, fileError("file.write", f.Path, err)
+		}
+		return nil, nil
+	}},
+	"close": {0, func(interpreter *Interpreter, f *LoxFile, arguments []interface{}) (interface{}, error) {
+		f.closed = true
+		delete(interpreter.files, f)
+		if err := f.file.Close(); err != nil {
+			return nil, fileError("file.close", f.Path, err)
+		}
+		return nil, nil
+	}},
+}
+
+// closeFiles closes the files the program left open.
+func (i *Interpreter) closeFiles() {
+	for f := range i.files {
+		f.closed = true
+		f.file.Close()
+	}
+	i.files = nil
+}
+
+// fileError describes a failed file operation. The RuntimeError wraps err,
+// so embedders can test for causes such as fs.ErrNotExist with errors.Is.
+func fileError(name string, path string, err error) error {
+	var pathError *fs.PathError
+	if errors.As(err, &pathError) {
+		err = pathError.Err
+	}
+	return &RuntimeError{Message: fmt.Sprintf("%v failed for '%v': %v.", name, path, err), Err: err}
+}
+
+// checkPath fails unless the sandbox lets programs touch path, and returns
+// the path natives should pass to the OS.
+func (i *Interpreter) checkPath(name string, path string) (string, error) {
+	resolved, ok := i.capabilities.allowsPath(path)
+	if !ok {
+		return "", &RuntimeError{
+			Message: fmt.Sprintf("Permission denied: '%v' can't access '%v'.", name, path),
+			Err:     ErrPermissionDenied,
+		}
+	}
+	return resolved, nil
+}
+
+// registerFileNative registers a native whose first argument is a path the
+// sandbox must allow. fn reports errors with path, as the program wrote it,
+// but touches target, the path the sandbox checked.
+func registerFileNative(name string, params int, fn func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error)) {
+	Natives.Register(&NativeFunction{
+		Name:     name,
+		Params:   params,
+		Requires: CapFS,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			path, err := argument[string](name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			target, err := interpreter.checkPath(name, path)
+			if err != nil {
+				return nil, err
+			}
+			return fn(interpreter, path, target, arguments)
+		},
+	})
+}
+
+func init() {
+	registerFileNative("readFile", 1, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, fileError("readFile", path, err)
+		}
+		return string(data), nil
+	})
+
+	// readLines drops line endings, and the empty line after a final one.
+	registerFileNative("readLines", 1, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, fileError("readLines", path, err)
+		}
+		text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		lines := make([]interface{}, 0)
+		if len(data) > 0 {
+			for _, line := range strings.Split(text, "\n") {
+				lines = append(lines, line)
+			}
+		}
+		return NewList(lines), nil
+	})
+
+	registerFileNative("writeFile", 2, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		text, err := argument[string]("writeFile", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, []byte(text), 0o644); err != nil {
+			return nil, fileError("writeFile", path, err)
+		}
+		return nil, nil
+	})
+
+	registerFileNative("appendFile", 2, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		text, err := argument[string]("appendFile", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fileError("appendFile", path, err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(text); err != nil {
+			return nil, fileError("appendFile", path, err)
+		}
+		return nil, nil
+	})
+
+	registerFileNative("exists", 1, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		_, err := os.Stat(target)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return nil, fileError("exists", path, err)
+		}
+		return true, nil
+	})
+
+	// listDir returns the names of the entries in a directory, sorted.
+	registerFileNative("listDir", 1, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		entries, err := os.ReadDir(target)
+		if err != nil {
+			return nil, fileError("listDir", path, err)
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		elements := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			elements = append(elements, name)
+		}
+		return NewList(elements), nil
+	})
+
+	// mkdir creates a directory along with any missing parents.
+	registerFileNative("mkdir", 1, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return nil, fileError("mkdir", path, err)
+		}
+		return nil, nil
+	})
+
+	// remove deletes a file or an empty directory. A symlink is removed
+	// itself, so only its directory is resolved.
+	registerFileNative("remove", 1, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		if base := filepath.Base(path); base != "." && base != ".." && base != string(filepath.Separator) {
+			dir, err := interpreter.checkPath("remove", filepath.Dir(path))
+			if err != nil {
+				return nil, err
+			}
+			target = filepath.Join(dir, base)
+		}
+		if err := os.Remove(target); err != nil {
+			return nil, fileError("remove", path, err)
+		}
+		return nil, nil
+	})
+
+	// open opens a file for reading ("r"), writing ("w") or appending ("a").
+	registerFileNative("open", 2, func(interpreter *Interpreter, path string, target string, arguments []interface{}) (interface{}, error) {
+		mode, err := argument[string]("open", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		var flags int
+		switch mode {
+		case "r":
+			flags = os.O_RDONLY
+		case "w":
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		case "a":
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		default:
+			return nil, invalidArgument("open", 1, `"r", "w" or "a"`)
+		}
+		file, err := os.OpenFile(target, flags, 0o644)
+		if err != nil {
+			return nil, fileError("open", path, err)
+		}
+		f := &LoxFile{Path: path, file: file}
+		if interpreter.files == nil {
+			interpreter.files = make(map[*LoxFile]bool)
+		}
+		interpreter.files[f] = true
+		return f, nil
+	})
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runInDir runs source with file access limited to dir, which the program
+// sees as the variable dir.
+func runInDir(t *testing.T, dir string, source string) (string, error) {
+	t.Helper()
+	var output bytes.Buffer
+	r := LoxRunner{
+		Output:       &output,
+		ErrorOutput:  io.Discard,
+		Capabilities: Capabilities{FS: true, FSRoots: []string{dir}},
+	}
+	err := r.Run(context.Background(), "var dir = \""+filepath.ToSlash(dir)+"\";\n"+source)
+	return output.String(), err
+}
+
+func TestFileNatives(t *testing.T) {
+	dir := t.TempDir()
+	output, err := runInDir(t, dir, `
+var path = "${dir}/notes.txt";
+print exists(path);
+var nl = chr(10);
+writeFile(path, "one" + nl);
+appendFile(path, "two" + chr(13) + nl + "three" + nl);
+print exists(path);
+print len(readFile(path));
+print readLines(path);
+mkdir("${dir}/sub/deeper");
+print listDir(dir);
+remove("${dir}/sub/deeper");
+print listDir("${dir}/sub");
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `false
+true
+15
+["one", "two", "three"]
+["notes.txt", "sub"]
+[]
+`
+	if output != want {
+		t.Errorf("output =\n%v\nwant\n%v", output, want)
+	}
+}
+
+func TestFileHandle(t *testing.T) {
+	dir := t.TempDir()
+	output, err := runInDir(t, dir, `
+var out = open("${dir}/log.txt", "w");
+out.write("first" + chr(10));
+out.write("second");
+out.close();
+
+var in = open("${dir}/log.txt", "r");
+print in;
+print in.readLine();
+print in.readLine();
+print in.readLine();
+in.close();
+in.readLine();
+`)
+	want := "<file " + filepath.ToSlash(dir) + "/log.txt>\nfirst\nsecond\nnil\n"
+	if output != want {
+		t.Errorf("output =\n%v\nwant\n%v", output, want)
+	}
+	if !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("reading a closed file: error = %v, want fs.ErrClosed", err)
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := runInDir(t, dir, `readFile("${dir}/missing.txt");`)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("reading a missing file: error = %v, want fs.ErrNotExist", err)
+	}
+	var runtimeError *RuntimeError
+	if !errors.As(err, &runtimeError) || !strings.HasPrefix(runtimeError.Message, "readFile failed for ") {
+		t.Errorf("reading a missing file: error = %#v", err)
+	}
+
+	// Paths outside the allowed directories are refused, even through a
+	// symlink, and a ".." after a link climbs from where the link points.
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	os.Mkdir(filepath.Join(outside, "sub"), 0o755)
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "sub"), filepath.Join(dir, "sublink")); err != nil {
+		t.Skip(err)
+	}
+	for _, path := range []string{outside + "/secret.txt", "${dir}/link/secret.txt", "${dir}/sublink/../secret.txt"} {
+		_, err = runInDir(t, dir, `readFile("`+filepath.ToSlash(path)+`");`)
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("reading %v: error = %v, want ErrPermissionDenied", path, err)
+		}
+	}
+
+	// Removing a link inside the sandbox removes the link, not its target.
+	os.WriteFile(filepath.Join(dir, "file.txt"), nil, 0o644)
+	os.Symlink(filepath.Join(dir, "file.txt"), filepath.Join(dir, "filelink"))
+	if _, err := runInDir(t, dir, `remove("${dir}/filelink");`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "filelink")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("removing a symlink: link still exists (%v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file.txt")); err != nil {
+		t.Errorf("removing a symlink: target was removed (%v)", err)
+	}
+}
+
+func TestFilesClosedAtExit(t *testing.T) {
+	for _, ending := range []string{"", "exit(1);", "nil();"} {
+		dir := t.TempDir()
+		r := LoxRunner{
+			Output:       io.Discard,
+			ErrorOutput:  io.Discard,
+			Capabilities: Capabilities{FS: true, FSRoots: []string{dir}},
+		}
+		r.Run(context.Background(), `var f = open("`+filepath.ToSlash(dir)+`/log.txt", "w");`+ending)
+		value, ok := r.Interpreter.Environment.Values["f"].(*LoxFile)
+		if !ok {
+			t.Fatalf("after %q: f = %v", ending, r.Interpreter.Environment.Values["f"])
+		}
+		if _, err := value.file.WriteString("x"); !errors.Is(err, fs.ErrClosed) {
+			t.Errorf("after %q: writing the file: error = %v, want fs.ErrClosed", ending, err)
+		}
+	}
+}
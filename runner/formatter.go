@@ -0,0 +1,308 @@
+package runner
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Formatter prints statements back out as Lox source in one canonical
+// layout: two-space indentation, single spaces around binary operators and
+// opening braces on the same line as the statement that owns them. Comments
+// are re-attached by line from the scanner's trivia, and a single blank line
+// between statements is kept.
+type Formatter struct {
+	Comments []Token
+
+	builder  strings.Builder
+	indent   int
+	next     int
+	lastLine int
+	opened   int
+}
+
+// Format parses source and returns it in canonical layout.
+func Format(source string) (string, error) {
+	lox := LoxRunner{ErrorOutput: io.Discard}
+	stmts := lox.Parse(source)
+	if lox.HadError {
+		messages := make([]string, len(lox.Errors))
+		for index, err := range lox.Errors {
+			messages[index] = err.Error()
+		}
+		return "", errors.New(strings.Join(messages, "\n"))
+	}
+
+	formatter := Formatter{Comments: lox.Scanner.Comments}
+	return formatter.Format(stmts), nil
+}
+
+func (f *Formatter) Format(stmts []Statement) string {
+	f.builder.Reset()
+	f.indent = 0
+	f.next = 0
+	f.lastLine = 0
+	f.opened = 0
+
+	for _, stmt := range stmts {
+		f.statement(stmt)
+	}
+	f.commentsBefore(math.MaxInt)
+
+	if f.builder.Len() == 0 {
+		return ""
+	}
+	return f.builder.String() + "\n"
+}
+
+func (f *Formatter) statement(stmt Statement) {
+	first, last := statementLines(stmt)
+	f.commentsBefore(first)
+	f.blankLine(first)
+	f.newline()
+	stmt.Accept(f)
+	f.trailingComments(last)
+	f.lastLine = last
+}
+
+// commentsBefore writes every pending comment that starts before line on a
+// line of its own.
+func (f *Formatter) commentsBefore(line int) {
+	for f.next < len(f.Comments) && f.Comments[f.next].Line < line {
+		comment := f.Comments[f.next]
+		f.blankLine(comment.Line)
+		f.newline()
+		f.write(strings.TrimRight(comment.Lexeme, " \t\r"))
+		// A comment moved into a block from before its brace, as one
+		// before an else is, doesn't open a blank line.
+		f.lastLine = comment.Line
+		if f.lastLine < f.opened {
+			f.lastLine = f.opened
+		}
+		f.next++
+	}
+}
+
+// trailingComments keeps comments that ended a statement's last line on
+// that line.
+func (f *Formatter) trailingComments(line int) {
+	for i := 0; f.next < len(f.Comments) && f.Comments[f.next].Line <= line; i++ {
+		if i == 0 {
+			f.write(" ")
+		} else {
+			f.newline()
+		}
+		f.write(strings.TrimRight(f.Comments[f.next].Lexeme, " \t\r"))
+		f.next++
+	}
+}
+
+func (f *Formatter) blankLine(line int) {
+	if f.lastLine > 0 && line > f.lastLine+1 {
+		f.builder.WriteString("\n")
+	}
+}
+
+func (f *Formatter) newline() {
+	if f.builder.Len() > 0 {
+		f.builder.WriteString("\n")
+	}
+	f.builder.WriteString(strings.Repeat("  ", f.indent))
+}
+
+func (f *Formatter) write(text string) {
+	f.builder.WriteString(text)
+}
+
+func (f *Formatter) block(bs *BlockStatement) {
+	f.write("{")
+	start := f.builder.Len()
+
+	f.indent++
+	f.lastLine = 0
+	f.opened = bs.LeftBrace.Line
+	for _, stmt := range bs.Statements {
+		f.statement(stmt)
+	}
+	if bs.RightBrace.Line > 0 {
+		f.commentsBefore(bs.RightBrace.Line)
+	}
+	f.indent--
+
+	if f.builder.Len() > start {
+		f.newline()
+	}
+	f.write("}")
+}
+
+// branch writes the body of an if. Blocks stay on the same line; anything
+// else is indented on the next.
+func (f *Formatter) branch(stmt Statement) {
+	if block, ok := stmt.(*BlockStatement); ok {
+		f.write(" ")
+		f.block(block)
+		return
+	}
+	f.indent++
+	f.lastLine = 0
+	f.statement(stmt)
+	f.indent--
+}
+
+func (f *Formatter) expression(expr Expression) string {
+	return expr.Accept(f).(string)
+}
+
+func (f *Formatter) VisitExpressionStatement(es *ExpressionStatement) {
+	f.write(f.expression(es.Expression) + ";")
+}
+
+func (f *Formatter) VisitPrintStatement(ps *PrintStatement) {
+	f.write("print " + f.expression(ps.Expression) + ";")
+}
+
+func (f *Formatter) VisitVarStatement(vs *VarStatement) {
+	if vs.Initializer == nil {
+		f.write("var " + vs.Name.Lexeme + ";")
+		return
+	}
+	f.write("var " + vs.Name.Lexeme + " = " + f.expression(vs.Initializer) + ";")
+}
+
+func (f *Formatter) VisitBlockStatement(bs *BlockStatement) {
+	f.block(bs)
+}
+
+func (f *Formatter) VisitIfStatement(is *IfStatement) {
+	f.write("if (" + f.expression(is.Condition) + ")")
+	f.branch(is.ThenBranch)
+	if is.ElseBranch == nil {
+		return
+	}
+
+	if _, ok := is.ThenBranch.(*BlockStatement); ok {
+		f.write(" else")
+	} else {
+		f.newline()
+		f.write("else")
+	}
+
+	if elseIf, ok := is.ElseBranch.(*IfStatement); ok {
+		f.write(" ")
+		elseIf.Accept(f)
+		return
+	}
+	f.branch(is.ElseBranch)
+}
+
+func (f *Formatter) VisitFunctionStatement(fs *FunctionStatement) {
+	params := make([]string, 0, len(fs.Params))
+	for _, param := range fs.Params {
+		params = append(params, param.Lexeme)
+	}
+	f.write("fun " + fs.Name.Lexeme + "(" + strings.Join(params, ", ") + ") ")
+	f.block(fs.Body)
+}
+
+func (f *Formatter) VisitReturnStatement(rs *ReturnStatement) {
+	if rs.Value == nil {
+		f.write("return;")
+		return
+	}
+	f.write("return " + f.expression(rs.Value) + ";")
+}
+
+func (f *Formatter) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	return f.expression(be.Left) + " " + be.Operator.Lexeme + " " + f.expression(be.Right)
+}
+
+func (f *Formatter) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	return "(" + f.expression(ge.Expression) + ")"
+}
+
+func (f *Formatter) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	if le.Token.Lexeme != "" {
+		return le.Token.Lexeme
+	}
+
+	// Literals loaded from a generated AST may only carry a value.
+	switch value := le.Token.Value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return "\"" + value + "\""
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return ""
+}
+
+func (f *Formatter) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	return ue.Operator.Lexeme + f.expression(ue.Right)
+}
+
+func (f *Formatter) VisitVarExpression(ve *VarExpression) interface{} {
+	return ve.Name.Lexeme
+}
+
+func (f *Formatter) VisitAssignExpression(ae *AssignExpression) interface{} {
+	return ae.Name.Lexeme + " = " + f.expression(ae.Value)
+}
+
+func (f *Formatter) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	return f.expression(le.Left) + " " + le.Operator.Lexeme + " " + f.expression(le.Right)
+}
+
+func (f *Formatter) VisitCallExpression(ce *CallExpression) interface{} {
+	arguments := make([]string, 0, len(ce.Arguments))
+	for _, argument := range ce.Arguments {
+		arguments = append(arguments, f.expression(argument))
+	}
+	return f.expression(ce.Callee) + "(" + strings.Join(arguments, ", ") + ")"
+}
+
+func (f *Formatter) VisitListExpression(le *ListExpression) interface{} {
+	elements := make([]string, 0, len(le.Elements))
+	for _, element := range le.Elements {
+		elements = append(elements, f.expression(element))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (f *Formatter) VisitIndexExpression(ie *IndexExpression) interface{} {
+	return f.expression(ie.Object) + "[" + f.expression(ie.Index) + "]"
+}
+
+func (f *Formatter) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	return f.expression(se.Object) + "[" + f.expression(se.Index) + "] = " + f.expression(se.Value)
+}
+
+func (f *Formatter) VisitMapExpression(me *MapExpression) interface{} {
+	entries := make([]string, 0, len(me.Keys))
+	for index, key := range me.Keys {
+		entries = append(entries, f.expression(key)+": "+f.expression(me.Values[index]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func (f *Formatter) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	var builder strings.Builder
+	builder.WriteString("\"")
+	for index, part := range ie.Strings {
+		text, _ := part.Value.(string)
+		builder.WriteString(text)
+		if index < len(ie.Expressions) {
+			builder.WriteString("${" + f.expression(ie.Expressions[index]) + "}")
+		}
+	}
+	builder.WriteString("\"")
+	return builder.String()
+}
+
+func (f *Formatter) VisitGetExpression(ge *GetExpression) interface{} {
+	return f.expression(ge.Object) + "." + ge.Name.Lexeme
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, path := range goldenFiles(t) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := Format(string(data))
+		if err != nil {
+			// Programs testing syntax errors can't be formatted.
+			continue
+		}
+		twice, err := Format(once)
+		if err != nil {
+			t.Errorf("%v: formatted source doesn't parse: %v", path, err)
+			continue
+		}
+		if twice != once {
+			t.Errorf("%v: formatting again changed\n%v\ninto\n%v", path, once, twice)
+		}
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"trailing",
+			"var a = 1;   // one\nprint a; // two\n",
+			"var a = 1; // one\nprint a; // two\n",
+		},
+		{
+			"after opening brace",
+			"if (a) { // why\n  print a;\n}\n",
+			"if (a) {\n  // why\n  print a;\n}\n",
+		},
+		{
+			"before else",
+			"if (a) {\n  print 1;\n}\n// otherwise\nelse {\n  print 2;\n}\n",
+			"if (a) {\n  print 1;\n} else {\n  // otherwise\n  print 2;\n}\n",
+		},
+		{
+			"end of block",
+			"fun f() {\n  return 1;\n  // unreachable\n}\n",
+			"fun f() {\n  return 1;\n  // unreachable\n}\n",
+		},
+		{
+			"blank lines",
+			"{\n\n  print 1;\n\n\n  // later\n  print 2;\n}\n",
+			"{\n  print 1;\n\n  // later\n  print 2;\n}\n",
+		},
+	}
+	for _, test := range tests {
+		got, err := Format(test.source)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%v: Format(%q) = %q, want %q", test.name, test.source, got, test.want)
+		}
+		if again, _ := Format(got); again != got {
+			t.Errorf("%v: formatting again gave %q", test.name, again)
+		}
+	}
+}
+
+func TestFormatSyntaxErrors(t *testing.T) {
+	_, err := Format("var a = ;\nprint );\n")
+	want := "[line 1] Error at ';': Expect expression.\n[line 2] Error at ')': Expect expression."
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %v", err, want)
+	}
+}
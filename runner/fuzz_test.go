@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addSeeds adds every program under goldenDir to the fuzzing corpus.
+func addSeeds(f *testing.F) {
+	filepath.WalkDir(goldenDir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && filepath.Ext(path) == ".lox" {
+			if data, err := os.ReadFile(path); err == nil {
+				f.Add(string(data))
+			}
+		}
+		return nil
+	})
+	f.Add("")
+	f.Add("\"unterminated")
+	f.Add("print 1.5 + 2;\n// comment")
+	f.Add("var é = \"ü\"; print é;")
+}
+
+func FuzzScanner(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, source string) {
+		r := LoxRunner{ErrorOutput: io.Discard}
+		tokens := r.Scan(source)
+
+		if len(tokens) == 0 || tokens[len(tokens)-1].Type != EOF {
+			t.Fatalf("tokens don't end with EOF: %v", tokens)
+		}
+
+		// Every lexeme appears in the source, in order. Invalid UTF-8 is
+		// scanned as replacement characters.
+		rest := string([]rune(source))
+		for _, token := range tokens[:len(tokens)-1] {
+			if token.Line < 1 || token.Column < 1 {
+				t.Fatalf("token %v at %v:%v", token.ToString(), token.Line, token.Column)
+			}
+			index := strings.Index(rest, token.Lexeme)
+			if index < 0 {
+				t.Fatalf("lexeme %q not found in the remaining source %q", token.Lexeme, rest)
+			}
+			rest = rest[index+len(token.Lexeme):]
+		}
+	})
+}
+
+func FuzzParser(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, source string) {
+		r := LoxRunner{ErrorOutput: io.Discard}
+		stmts := r.Parse(source)
+		if r.HadError {
+			if len(r.Errors) == 0 {
+				t.Fatal("HadError is set but no errors were collected")
+			}
+			return
+		}
+
+		printer := AstPrinter{}
+		printer.Print(stmts)
+
+		// Formatting a program that parses gives one that still does.
+		formatted, err := Format(source)
+		if err != nil {
+			t.Fatalf("Format: %v", err)
+		}
+		reparsed := LoxRunner{ErrorOutput: io.Discard}
+		reparsed.Parse(formatted)
+		if reparsed.HadError {
+			t.Fatalf("formatted source doesn't parse: %v\n%v", reparsed.Errors, formatted)
+		}
+	})
+}
+
+func FuzzRun(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, source string) {
+		r := LoxRunner{
+			Output:      io.Discard,
+			ErrorOutput: io.Discard,
+			Limits:      Limits{MaxSteps: 10000, MaxDepth: 200, Timeout: time.Second},
+		}
+		err := r.Run(context.Background(), source)
+		if err == nil {
+			return
+		}
+
+		var runtimeError *RuntimeError
+		if !errors.As(err, &runtimeError) {
+			t.Fatalf("error isn't a RuntimeError: %#v", err)
+		}
+		var goError runtime.Error
+		if errors.As(err, &goError) {
+			t.Fatalf("Go runtime panic: %v", goError)
+		}
+		if errors.Is(err, errInternal) {
+			t.Fatalf("Go panic: %v", err)
+		}
+	})
+}
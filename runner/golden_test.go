@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// goldenDir holds the .lox programs checked by TestGolden.
+const goldenDir = "../tests"
+
+// Expectations are written as comments in the programs, as in the reference
+// Lox test suite:
+//
+//	print 1 + 2; // expect: 3
+//	print nil + 1; // expect runtime error: Operands must be numbers.
+//	// [line 3] Error at ';': Expect expression.
+//	var a = ); // Error at ')': Expect expression.
+//
+// Errors without a line are expected on the line of the comment. Errors
+// marked for the C implementation ("[c line N]") are skipped and those for
+// the Java one ("[java line N]") are kept. A program with the line
+// "// strict mode" runs in strict mode.
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectSyntaxError  = regexp.MustCompile(`// (\[((java|c) )?line (\d+)\] )?(Error.*)`)
+)
+
+type golden struct {
+	output       []string
+	syntaxErrors []string
+	runtimeError string
+	runtimeLine  int
+	strict       bool
+}
+
+func parseGolden(source string) golden {
+	var expected golden
+	for index, line := range strings.Split(source, "\n") {
+		number := index + 1
+		if strings.TrimSpace(line) == "// strict mode" {
+			expected.strict = true
+			continue
+		}
+		if match := expectOutput.FindStringSubmatch(line); match != nil {
+			expected.output = append(expected.output, match[1])
+			continue
+		}
+		if match := expectRuntimeError.FindStringSubmatch(line); match != nil {
+			expected.runtimeError = match[1]
+			expected.runtimeLine = number
+			continue
+		}
+		if match := expectSyntaxError.FindStringSubmatch(line); match != nil {
+			if match[3] == "c" {
+				continue
+			}
+			if match[4] != "" {
+				number, _ = strconv.Atoi(match[4])
+			}
+			expected.syntaxErrors = append(expected.syntaxErrors, "[line "+strconv.Itoa(number)+"] "+match[5])
+		}
+	}
+	return expected
+}
+
+// goldenFiles returns the paths of the programs in goldenDir.
+func goldenFiles(t *testing.T) []string {
+	t.Helper()
+	var paths []string
+	err := filepath.WalkDir(goldenDir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && filepath.Ext(path) == ".lox" {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no .lox files in %v", goldenDir)
+	}
+	return paths
+}
+
+func TestGolden(t *testing.T) {
+	for _, path := range goldenFiles(t) {
+		path := path
+		name, _ := filepath.Rel(goldenDir, path)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			t.Parallel()
+			runGolden(t, path)
+		})
+	}
+}
+
+func runGolden(t *testing.T, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := parseGolden(string(data))
+
+	var output, errorOutput bytes.Buffer
+	r := LoxRunner{
+		Output:      &output,
+		ErrorOutput: &errorOutput,
+		Limits:      Limits{Timeout: 10 * time.Second},
+		Strict:      expected.strict,
+	}
+	runErr := r.Run(context.Background(), string(data))
+
+	var syntaxErrors []string
+	for _, syntaxError := range r.Errors {
+		syntaxErrors = append(syntaxErrors, syntaxError.Error())
+	}
+	compareLines(t, "errors", expected.syntaxErrors, syntaxErrors)
+
+	var runtimeError *RuntimeError
+	switch {
+	case expected.runtimeError == "" && runErr != nil:
+		t.Errorf("unexpected runtime error: %v", runErr)
+	case expected.runtimeError != "" && runErr == nil:
+		t.Errorf("expected runtime error %q, got none", expected.runtimeError)
+	case errors.As(runErr, &runtimeError):
+		if runtimeError.Message != expected.runtimeError {
+			t.Errorf("runtime error = %q, want %q", runtimeError.Message, expected.runtimeError)
+		}
+		if runtimeError.Line != expected.runtimeLine {
+			t.Errorf("runtime error on line %v, want line %v", runtimeError.Line, expected.runtimeLine)
+		}
+	}
+
+	compareLines(t, "output", expected.output, splitLines(output.String()))
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func compareLines(t *testing.T, what string, want []string, got []string) {
+	t.Helper()
+	for index := 0; index < len(want) || index < len(got); index++ {
+		switch {
+		case index >= len(got):
+			t.Errorf("missing %v line %v: %q", what, index+1, want[index])
+		case index >= len(want):
+			t.Errorf("unexpected %v line %v: %q", what, index+1, got[index])
+		case want[index] != got[index]:
+			t.Errorf("%v line %v = %q, want %q", what, index+1, got[index], want[index])
+		}
+	}
+}
@@ -1,40 +1,124 @@
 package runner
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
 )
 
 type Interpreter struct {
 	Environment *Environment
+	Limits      Limits
+	Hooks       Hooks
+	// Profiler, when set, records where the program spends its time.
+	Profiler *Profiler
+	// Coverage, when set, records which parts of the program run.
+	Coverage *Coverage
+	// Tracer, when set, logs the program as it runs.
+	Tracer *Tracer
+	// Output receives everything the program prints.
+	Output io.Writer
+	// Input is read by input and readLine.
+	Input io.Reader
+	// Strict makes dividing by zero a runtime error.
+	Strict bool
+
+	capabilities Capabilities
+	ctx          context.Context
+	steps        int
+	depth        int
+	frames       []*Frame
+	random       *rand.Rand
+	input        *bufio.Reader
+	// files holds the files the program has opened and not closed, so
+	// they can be closed when it ends.
+	files map[*LoxFile]bool
 }
 
-func NewInterpreter() Interpreter {
+func NewInterpreter(capabilities Capabilities) Interpreter {
+	globals := NewEnvironment(nil)
+	Natives.define(globals)
 	return Interpreter{
-		Environment: NewEnvironment(nil),
+		Environment:  globals,
+		Output:       os.Stdout,
+		Input:        os.Stdin,
+		capabilities: capabilities,
 	}
 }
 
-func (i *Interpreter) interpret(stmts []Statement) {
+func (i *Interpreter) interpret(ctx context.Context, stmts []Statement) error {
+	return i.run(ctx, func() {
+		for _, stmt := range stmts {
+			i.execute(stmt)
+		}
+	})
+}
+
+// evaluateTop evaluates an expression on its own, outside of any statement.
+func (i *Interpreter) evaluateTop(ctx context.Context, expr Expression) (value interface{}, err error) {
+	err = i.run(ctx, func() {
+		value = i.evaluate(expr)
+	})
+	return value, err
+}
+
+// run prepares the interpreter to run body under ctx and i.Limits, turning
+// anything body panics with into a RuntimeError.
+func (i *Interpreter) run(ctx context.Context, body func()) (err error) {
+	if i.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.Limits.Timeout)
+		defer cancel()
+	}
+	i.ctx = ctx
+	i.steps = 0
+	i.depth = 0
+	i.frames = nil
+	i.pushFrame("<script>")
+
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println(r)
+			runtimeError := toRuntimeError(r)
+			if i.Tracer != nil && !isExit(runtimeError) {
+				i.Tracer.fail(i, runtimeError)
+			}
+			err = runtimeError
 		}
 	}()
-	for _, stmt := range stmts {
-		i.execute(stmt)
-	}
+	body()
+	return nil
 }
 
 func (i *Interpreter) execute(stmt Statement) {
+	line := StatementLine(stmt)
+	i.frames[len(i.frames)-1].Line = line
+	i.enter()
+	defer i.leave()
+
+	if i.Coverage != nil {
+		i.Coverage.statement(stmt)
+	}
+	if i.Tracer != nil {
+		i.Tracer.statement(i, stmt, line)
+	}
+	if i.Profiler != nil {
+		i.Profiler.enterStatement(line)
+		defer i.Profiler.leaveStatement(i)
+	}
+	if i.Hooks.BeforeStatement != nil {
+		i.Hooks.BeforeStatement(i, stmt)
+	}
 	stmt.Accept(i)
 }
 
 func (i *Interpreter) executeBlock(statements []Statement, env *Environment) {
 	previous := i.Environment
 	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println(r)
-		}
 		i.Environment = previous
 	}()
 
@@ -46,6 +130,8 @@ func (i *Interpreter) executeBlock(statements []Statement, env *Environment) {
 }
 
 func (i *Interpreter) evaluate(exp Expression) interface{} {
+	i.enter()
+	defer i.leave()
 	return exp.Accept(i)
 }
 
@@ -55,20 +141,43 @@ func (i *Interpreter) VisitVarStatement(vs *VarStatement) {
 		value = i.evaluate(vs.Initializer)
 	}
 
-	i.Environment.Define(vs.Name.Lexeme, value)
+	i.define(vs.Name.Lexeme, value)
+}
+
+func (i *Interpreter) define(name string, value interface{}) {
+	if i.Tracer != nil {
+		i.Tracer.define(i, name, value)
+	}
+	i.Environment.Define(name, value)
 }
 
 func (i *Interpreter) VisitIfStatement(is *IfStatement) {
 	if i.isTruthy(i.evaluate(is.Condition)) {
+		i.coverBranch(is, 0)
 		i.execute(is.ThenBranch)
-	} else if is.ElseBranch != nil {
-		i.execute(is.ElseBranch)
+	} else {
+		i.coverBranch(is, 1)
+		if is.ElseBranch != nil {
+			i.execute(is.ElseBranch)
+		}
 	}
 }
 
 func (i *Interpreter) VisitPrintStatement(ps *PrintStatement) {
 	value := i.evaluate(ps.Expression)
-	fmt.Println(value)
+	fmt.Fprintln(i.Output, Stringify(value))
+}
+
+func (i *Interpreter) VisitFunctionStatement(fs *FunctionStatement) {
+	i.define(fs.Name.Lexeme, &LoxFunction{Declaration: fs, Closure: i.Environment})
+}
+
+func (i *Interpreter) VisitReturnStatement(rs *ReturnStatement) {
+	var value interface{}
+	if rs.Value != nil {
+		value = i.evaluate(rs.Value)
+	}
+	panic(returnValue{value})
 }
 
 func (i *Interpreter) VisitExpressionStatement(es *ExpressionStatement) {
@@ -80,7 +189,7 @@ func (i *Interpreter) VisitBlockStatement(bs *BlockStatement) {
 }
 
 func (i *Interpreter) VisitVarExpression(ve *VarExpression) interface{} {
-	return i.Environment.Get(ve.Name.Lexeme)
+	return i.Environment.Get(ve.Name)
 }
 
 func (i *Interpreter) VisitBinaryExpression(be *BinaryExpression) interface{} {
@@ -89,7 +198,7 @@ func (i *Interpreter) VisitBinaryExpression(be *BinaryExpression) interface{} {
 
 	validateNum := func() (float64, float64) {
 		return validateOperands[float64](
-			be.Operator.Type,
+			be.Operator,
 			left,
 			right,
 		)
@@ -101,10 +210,18 @@ func (i *Interpreter) VisitBinaryExpression(be *BinaryExpression) interface{} {
 		return leftVal - rightVal
 	case SLASH:
 		leftVal, rightVal := validateNum()
+		i.checkDivisor(be.Operator, rightVal)
 		return leftVal / rightVal
 	case STAR:
 		leftVal, rightVal := validateNum()
 		return leftVal * rightVal
+	case PERCENT:
+		leftVal, rightVal := validateNum()
+		i.checkDivisor(be.Operator, rightVal)
+		return math.Mod(leftVal, rightVal)
+	case STAR_STAR:
+		leftVal, rightVal := validateNum()
+		return math.Pow(leftVal, rightVal)
 	case PLUS:
 		leftString, leftOk := left.(string)
 		rightString, rightOk := right.(string)
@@ -118,7 +235,7 @@ func (i *Interpreter) VisitBinaryExpression(be *BinaryExpression) interface{} {
 			return leftNum + rightNum
 		}
 
-		panic(fmt.Errorf("invalid operands for '%v': %v, %v", be.Operator.Type, left, right))
+		panic(newRuntimeError(be.Operator, "Operands must be two numbers or two strings."))
 	case GREATER:
 		leftVal, rightVal := validateNum()
 		return leftVal > rightVal
@@ -154,7 +271,7 @@ func (i *Interpreter) VisitUnaryExpression(ue *UnaryExpression) interface{} {
 	case MINUS:
 		value, ok := right.(float64)
 		if !ok {
-			panic(fmt.Errorf("invalid operand for '%v': %v", MINUS, right))
+			panic(newRuntimeError(ue.Operator, "Operand must be a number."))
 		}
 		return -value
 	case BANG:
@@ -166,7 +283,10 @@ func (i *Interpreter) VisitUnaryExpression(ue *UnaryExpression) interface{} {
 
 func (i *Interpreter) VisitAssignExpression(ae *AssignExpression) interface{} {
 	value := i.evaluate(ae.Value)
-	i.Environment.Assign(ae.Name.Lexeme, value)
+	if i.Tracer != nil {
+		i.Tracer.assign(i, ae.Name.Lexeme, i.Environment.Get(ae.Name), value)
+	}
+	i.Environment.Assign(ae.Name, value)
 	return value
 }
 
@@ -174,16 +294,206 @@ func (i *Interpreter) VisitLogicalExpression(le *LogicalExpression) interface{}
 	left := i.evaluate(le.Left)
 	if le.Operator.Type == OR {
 		if i.isTruthy(left) {
+			i.coverBranch(le, 0)
 			return left
 		}
 	} else {
 		if !i.isTruthy(left) {
+			i.coverBranch(le, 0)
 			return left
 		}
 	}
+	i.coverBranch(le, 1)
 	return i.evaluate(le.Right)
 }
 
+// coverBranch records that a branch of an if or logical expression was
+// taken: 0 for the then branch or short circuit, 1 for the other.
+func (i *Interpreter) coverBranch(node interface{}, taken int) {
+	if i.Coverage != nil {
+		i.Coverage.branch(node, taken)
+	}
+}
+
+func (i *Interpreter) VisitCallExpression(ce *CallExpression) interface{} {
+	callee := i.evaluate(ce.Callee)
+
+	arguments := make([]interface{}, 0, len(ce.Arguments))
+	for _, argument := range ce.Arguments {
+		arguments = append(arguments, i.evaluate(argument))
+	}
+
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		panic(newRuntimeError(ce.Paren, "Can only call functions and classes."))
+	}
+
+	if arity := function.Arity(); arity >= 0 && len(arguments) != arity {
+		panic(newRuntimeError(ce.Paren, "Expected %v arguments but got %v.", arity, len(arguments)))
+	}
+
+	if i.Profiler != nil {
+		i.Profiler.enterCall(function)
+		defer i.Profiler.leaveCall()
+	}
+	if i.Tracer != nil {
+		i.Tracer.call(i, ce.Paren.Line, function, arguments)
+	}
+	result := function.Call(i, ce.Paren, arguments)
+	if i.Tracer != nil {
+		i.Tracer.exit(i, ce.Paren.Line, function, result)
+	}
+	return result
+}
+
+func (i *Interpreter) VisitListExpression(le *ListExpression) interface{} {
+	elements := make([]interface{}, 0, len(le.Elements))
+	for _, element := range le.Elements {
+		elements = append(elements, i.evaluate(element))
+	}
+	return NewList(elements)
+}
+
+func (i *Interpreter) VisitIndexExpression(ie *IndexExpression) interface{} {
+	object := i.evaluate(ie.Object)
+	index := i.evaluate(ie.Index)
+
+	var value interface{}
+	var err error
+	switch collection := object.(type) {
+	case *LoxList:
+		var position int
+		if position, err = collection.index(index, false); err == nil {
+			value = collection.Elements[position]
+		}
+	case *LoxMap:
+		value, err = collection.lookup(index)
+	case string:
+		// Strings are indexed by code point.
+		characters := []rune(collection)
+		var position int
+		if position, err = sequenceIndex("String", index, len(characters), false); err == nil {
+			value = string(characters[position])
+		}
+	default:
+		panic(newRuntimeError(ie.Bracket, "Only lists, maps and strings can be indexed."))
+	}
+	if err != nil {
+		panic(&RuntimeError{Line: ie.Bracket.Line, Message: err.Error()})
+	}
+	return value
+}
+
+func (i *Interpreter) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	object := i.evaluate(se.Object)
+	index := i.evaluate(se.Index)
+	value := i.evaluate(se.Value)
+
+	var err error
+	switch collection := object.(type) {
+	case *LoxList:
+		var position int
+		if position, err = collection.index(index, false); err == nil {
+			collection.Elements[position] = value
+		}
+	case *LoxMap:
+		err = collection.Set(index, value)
+	case string:
+		panic(newRuntimeError(se.Bracket, "Strings can't be changed."))
+	default:
+		panic(newRuntimeError(se.Bracket, "Only lists and maps can be indexed."))
+	}
+	if err != nil {
+		panic(&RuntimeError{Line: se.Bracket.Line, Message: err.Error()})
+	}
+	return value
+}
+
+func (i *Interpreter) VisitMapExpression(me *MapExpression) interface{} {
+	m := NewMap()
+	for index, key := range me.Keys {
+		key := i.evaluate(key)
+		if err := m.Set(key, i.evaluate(me.Values[index])); err != nil {
+			panic(&RuntimeError{Line: me.Brace.Line, Message: err.Error()})
+		}
+	}
+	return m
+}
+
+func (i *Interpreter) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	var builder strings.Builder
+	for index, part := range ie.Strings {
+		text, _ := part.Value.(string)
+		builder.WriteString(text)
+		if index < len(ie.Expressions) {
+			builder.WriteString(Stringify(i.evaluate(ie.Expressions[index])))
+		}
+	}
+	return builder.String()
+}
+
+func (i *Interpreter) VisitGetExpression(ge *GetExpression) interface{} {
+	var value interface{}
+	var ok bool
+	switch object := i.evaluate(ge.Object).(type) {
+	case *LoxMap:
+		value, ok, _ = object.Get(ge.Name.Lexeme)
+	case LoxObject:
+		value, ok = object.Property(ge.Name.Lexeme)
+	default:
+		panic(newRuntimeError(ge.Name, "Only maps and objects have properties."))
+	}
+	if !ok {
+		panic(newRuntimeError(ge.Name, "Undefined property '%v'.", ge.Name.Lexeme))
+	}
+	return value
+}
+
+// checkDivisor raises an error for dividing by zero in strict mode. Outside
+// it, division follows IEEE 754: x / 0 is an infinity with the sign of x,
+// and 0 / 0 and x % 0 are NaN.
+func (i *Interpreter) checkDivisor(operator Token, divisor float64) {
+	if i.Strict && divisor == 0 {
+		panic(newRuntimeError(operator, "Division by zero."))
+	}
+}
+
+// Stringify formats a value the way print shows it.
+func Stringify(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return formatNumber(value)
+	}
+	return fmt.Sprint(value)
+}
+
+// TypeName returns the name of a value's type as Lox programs see it.
+func TypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *LoxList:
+		return "list"
+	case *LoxMap:
+		return "map"
+	case *LoxFile:
+		return "file"
+	case *NativeFunction:
+		return "native function"
+	case LoxCallable:
+		return "function"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
 func (i *Interpreter) isTruthy(value interface{}) bool {
 
 	// null values false
@@ -201,15 +511,60 @@ func (i *Interpreter) isTruthy(value interface{}) bool {
 	return true
 }
 
+// isEqual compares lists and maps by their contents and everything else by
+// identity. NaN equals nothing, not even itself, so a collection holding NaN
+// doesn't equal itself either.
 func (i *Interpreter) isEqual(left interface{}, right interface{}) bool {
+	return valuesEqual(left, right, make(map[[2]interface{}]bool))
+}
+
+// valuesEqual compares two values, assuming pairs of collections already
+// being compared are equal so that collections containing themselves
+// compare.
+func valuesEqual(left interface{}, right interface{}, comparing map[[2]interface{}]bool) bool {
+	pair := [2]interface{}{left, right}
+	switch leftValue := left.(type) {
+	case *LoxList:
+		rightValue, ok := right.(*LoxList)
+		if !ok || len(leftValue.Elements) != len(rightValue.Elements) {
+			return false
+		}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		for index := range leftValue.Elements {
+			if !valuesEqual(leftValue.Elements[index], rightValue.Elements[index], comparing) {
+				return false
+			}
+		}
+		return true
+	case *LoxMap:
+		rightValue, ok := right.(*LoxMap)
+		if !ok || leftValue.Len() != rightValue.Len() {
+			return false
+		}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		for key, value := range leftValue.values {
+			other, ok := rightValue.values[key]
+			if !ok || !valuesEqual(value, other, comparing) {
+				return false
+			}
+		}
+		return true
+	}
 	return left == right
 }
 
-func validateOperands[T string | float64 | bool](operator TokenType, left interface{}, right interface{}) (T, T) {
+func validateOperands[T string | float64 | bool](operator Token, left interface{}, right interface{}) (T, T) {
 	leftVal, leftOk := left.(T)
 	rightVal, rightOk := right.(T)
 	if !leftOk || !rightOk {
-		panic(fmt.Errorf("invalid operands for '%v': %v, %v", operator, left, right))
+		var zero T
+		panic(newRuntimeError(operator, "Operands must be %vs.", TypeName(zero)))
 	}
 	return leftVal, rightVal
 }
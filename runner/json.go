@@ -0,0 +1,424 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type jsonToken struct {
+	Type   string      `json:"type"`
+	Lexeme string      `json:"lexeme"`
+	Value  interface{} `json:"value"`
+	Line   int         `json:"line"`
+	Column int         `json:"column"`
+}
+
+// tokenTypes maps the generated TokenType names back to their values.
+var tokenTypes = func() map[string]TokenType {
+	types := make(map[string]TokenType)
+	for ttype := LEFT_PAREN; ttype <= EOF; ttype++ {
+		types[ttype.String()] = ttype
+	}
+	return types
+}()
+
+func (t Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonToken{
+		Type:   t.Type.String(),
+		Lexeme: t.Lexeme,
+		Value:  t.Value,
+		Line:   t.Line,
+		Column: t.Column,
+	})
+}
+
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var token jsonToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return err
+	}
+	ttype, ok := tokenTypes[token.Type]
+	if !ok {
+		return fmt.Errorf("unknown token type %q", token.Type)
+	}
+	*t = Token{
+		Type:   ttype,
+		Lexeme: token.Lexeme,
+		Value:  token.Value,
+		Line:   token.Line,
+		Column: token.Column,
+	}
+	return nil
+}
+
+// MarshalAst serializes statements as JSON. Every node is an object whose
+// "kind" field names its Go type, with its fields under lowerCamelCase keys.
+func MarshalAst(stmts []Statement) ([]byte, error) {
+	encoder := astEncoder{}
+	nodes := make([]interface{}, 0, len(stmts))
+	for _, stmt := range stmts {
+		nodes = append(nodes, encoder.statement(stmt))
+	}
+	return json.MarshalIndent(nodes, "", "  ")
+}
+
+// UnmarshalAst rebuilds statements from the output of MarshalAst.
+func UnmarshalAst(data []byte) (stmts []Statement, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			decodeErr, ok := r.(astDecodeError)
+			if !ok {
+				panic(r)
+			}
+			stmts, err = nil, decodeErr.err
+		}
+	}()
+
+	var nodes []json.RawMessage
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+	stmts = make([]Statement, 0, len(nodes))
+	for index, node := range nodes {
+		if isNull(node) {
+			return nil, fmt.Errorf("statement %v: missing statement", index)
+		}
+		stmts = append(stmts, decodeStatement(node))
+	}
+	return stmts, nil
+}
+
+type node map[string]interface{}
+
+type astEncoder struct {
+	result node
+}
+
+func (e *astEncoder) statement(stmt Statement) interface{} {
+	if stmt == nil {
+		return nil
+	}
+	stmt.Accept(e)
+	return e.result
+}
+
+func (e *astEncoder) expression(expr Expression) interface{} {
+	if expr == nil {
+		return nil
+	}
+	return expr.Accept(e)
+}
+
+func (e *astEncoder) statements(stmts []Statement) []interface{} {
+	nodes := make([]interface{}, 0, len(stmts))
+	for _, stmt := range stmts {
+		nodes = append(nodes, e.statement(stmt))
+	}
+	return nodes
+}
+
+func (e *astEncoder) expressions(exprs []Expression) []interface{} {
+	nodes := make([]interface{}, 0, len(exprs))
+	for _, expr := range exprs {
+		nodes = append(nodes, e.expression(expr))
+	}
+	return nodes
+}
+
+func (e *astEncoder) VisitExpressionStatement(es *ExpressionStatement) {
+	e.result = node{"kind": "ExpressionStatement", "expression": e.expression(es.Expression)}
+}
+
+func (e *astEncoder) VisitPrintStatement(ps *PrintStatement) {
+	e.result = node{"kind": "PrintStatement", "keyword": ps.Keyword, "expression": e.expression(ps.Expression)}
+}
+
+func (e *astEncoder) VisitVarStatement(vs *VarStatement) {
+	e.result = node{"kind": "VarStatement", "name": vs.Name, "initializer": e.expression(vs.Initializer)}
+}
+
+func (e *astEncoder) VisitBlockStatement(bs *BlockStatement) {
+	statements := e.statements(bs.Statements)
+	e.result = node{"kind": "BlockStatement", "leftBrace": bs.LeftBrace, "statements": statements, "rightBrace": bs.RightBrace}
+}
+
+func (e *astEncoder) VisitIfStatement(is *IfStatement) {
+	condition := e.expression(is.Condition)
+	thenBranch := e.statement(is.ThenBranch)
+	elseBranch := e.statement(is.ElseBranch)
+	e.result = node{"kind": "IfStatement", "keyword": is.Keyword, "condition": condition, "thenBranch": thenBranch, "elseBranch": elseBranch}
+}
+
+func (e *astEncoder) VisitFunctionStatement(fs *FunctionStatement) {
+	body := e.statement(fs.Body)
+	e.result = node{"kind": "FunctionStatement", "keyword": fs.Keyword, "name": fs.Name, "params": fs.Params, "body": body}
+}
+
+func (e *astEncoder) VisitReturnStatement(rs *ReturnStatement) {
+	e.result = node{"kind": "ReturnStatement", "keyword": rs.Keyword, "value": e.expression(rs.Value)}
+}
+
+func (e *astEncoder) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	return node{"kind": "BinaryExpression", "operator": be.Operator, "left": e.expression(be.Left), "right": e.expression(be.Right)}
+}
+
+func (e *astEncoder) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	return node{"kind": "GroupingExpression", "expression": e.expression(ge.Expression)}
+}
+
+func (e *astEncoder) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	return node{"kind": "LiteralExpression", "token": le.Token}
+}
+
+func (e *astEncoder) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	return node{"kind": "UnaryExpression", "operator": ue.Operator, "right": e.expression(ue.Right)}
+}
+
+func (e *astEncoder) VisitVarExpression(ve *VarExpression) interface{} {
+	return node{"kind": "VarExpression", "name": ve.Name}
+}
+
+func (e *astEncoder) VisitAssignExpression(ae *AssignExpression) interface{} {
+	return node{"kind": "AssignExpression", "name": ae.Name, "value": e.expression(ae.Value)}
+}
+
+func (e *astEncoder) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	return node{"kind": "LogicalExpression", "operator": le.Operator, "left": e.expression(le.Left), "right": e.expression(le.Right)}
+}
+
+func (e *astEncoder) VisitCallExpression(ce *CallExpression) interface{} {
+	return node{"kind": "CallExpression", "callee": e.expression(ce.Callee), "paren": ce.Paren, "arguments": e.expressions(ce.Arguments)}
+}
+
+func (e *astEncoder) VisitListExpression(le *ListExpression) interface{} {
+	return node{"kind": "ListExpression", "bracket": le.Bracket, "elements": e.expressions(le.Elements)}
+}
+
+func (e *astEncoder) VisitIndexExpression(ie *IndexExpression) interface{} {
+	return node{"kind": "IndexExpression", "object": e.expression(ie.Object), "bracket": ie.Bracket, "index": e.expression(ie.Index)}
+}
+
+func (e *astEncoder) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	return node{"kind": "SetIndexExpression", "object": e.expression(se.Object), "bracket": se.Bracket, "index": e.expression(se.Index), "value": e.expression(se.Value)}
+}
+
+func (e *astEncoder) VisitMapExpression(me *MapExpression) interface{} {
+	return node{"kind": "MapExpression", "brace": me.Brace, "keys": e.expressions(me.Keys), "values": e.expressions(me.Values)}
+}
+
+func (e *astEncoder) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	return node{"kind": "InterpolationExpression", "strings": ie.Strings, "expressions": e.expressions(ie.Expressions)}
+}
+
+func (e *astEncoder) VisitGetExpression(ge *GetExpression) interface{} {
+	return node{"kind": "GetExpression", "object": e.expression(ge.Object), "name": ge.Name}
+}
+
+type astDecodeError struct {
+	err error
+}
+
+type rawNode map[string]json.RawMessage
+
+func decodeError(format string, args ...interface{}) astDecodeError {
+	return astDecodeError{fmt.Errorf(format, args...)}
+}
+
+func decodeNode(data json.RawMessage) (rawNode, string) {
+	if isNull(data) {
+		return nil, ""
+	}
+	var raw rawNode
+	if err := json.Unmarshal(data, &raw); err != nil {
+		panic(astDecodeError{err})
+	}
+	var kind string
+	if err := json.Unmarshal(raw["kind"], &kind); err != nil {
+		panic(decodeError("node is missing its kind: %s", data))
+	}
+	return raw, kind
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+func (raw rawNode) token(field string) Token {
+	var token Token
+	if err := json.Unmarshal(raw[field], &token); err != nil {
+		panic(decodeError("field %q: %v", field, err))
+	}
+	return token
+}
+
+// position decodes a token that only records where a node came from, so
+// generated ASTs may leave it out.
+func (raw rawNode) position(field string) Token {
+	if isNull(raw[field]) {
+		return Token{}
+	}
+	return raw.token(field)
+}
+
+func (raw rawNode) list(field string) []json.RawMessage {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw[field], &items); err != nil {
+		panic(decodeError("field %q: %v", field, err))
+	}
+	return items
+}
+
+// statement and expression decode a field that must be present; optional
+// fields are decoded with decodeStatement and decodeExpression directly.
+func (raw rawNode) statement(field string) Statement {
+	if isNull(raw[field]) {
+		panic(decodeError("missing field %q", field))
+	}
+	return decodeStatement(raw[field])
+}
+
+func (raw rawNode) expression(field string) Expression {
+	if isNull(raw[field]) {
+		panic(decodeError("missing field %q", field))
+	}
+	return decodeExpression(raw[field])
+}
+
+func (raw rawNode) statements(field string) []Statement {
+	items := raw.list(field)
+	stmts := make([]Statement, 0, len(items))
+	for _, item := range items {
+		if isNull(item) {
+			panic(decodeError("field %q: missing statement", field))
+		}
+		stmts = append(stmts, decodeStatement(item))
+	}
+	return stmts
+}
+
+func (raw rawNode) tokens(field string) []Token {
+	var tokens []Token
+	if err := json.Unmarshal(raw[field], &tokens); err != nil {
+		panic(decodeError("field %q: %v", field, err))
+	}
+	return tokens
+}
+
+func (raw rawNode) expressions(field string) []Expression {
+	items := raw.list(field)
+	exprs := make([]Expression, 0, len(items))
+	for _, item := range items {
+		if isNull(item) {
+			panic(decodeError("field %q: missing expression", field))
+		}
+		exprs = append(exprs, decodeExpression(item))
+	}
+	return exprs
+}
+
+func decodeStatement(data json.RawMessage) Statement {
+	raw, kind := decodeNode(data)
+	switch kind {
+	case "":
+		return nil
+	case "ExpressionStatement":
+		return &ExpressionStatement{Expression: raw.expression("expression")}
+	case "PrintStatement":
+		return &PrintStatement{Keyword: raw.position("keyword"), Expression: raw.expression("expression")}
+	case "VarStatement":
+		return &VarStatement{Name: raw.token("name"), Initializer: decodeExpression(raw["initializer"])}
+	case "BlockStatement":
+		return &BlockStatement{
+			LeftBrace:  raw.position("leftBrace"),
+			Statements: raw.statements("statements"),
+			RightBrace: raw.position("rightBrace"),
+		}
+	case "IfStatement":
+		return &IfStatement{
+			Keyword:    raw.position("keyword"),
+			Condition:  raw.expression("condition"),
+			ThenBranch: raw.statement("thenBranch"),
+			ElseBranch: decodeStatement(raw["elseBranch"]),
+		}
+	case "FunctionStatement":
+		body, ok := raw.statement("body").(*BlockStatement)
+		if !ok {
+			panic(decodeError("field \"body\": expected a BlockStatement"))
+		}
+		return &FunctionStatement{
+			Keyword: raw.position("keyword"),
+			Name:    raw.token("name"),
+			Params:  raw.tokens("params"),
+			Body:    body,
+		}
+	case "ReturnStatement":
+		return &ReturnStatement{Keyword: raw.position("keyword"), Value: decodeExpression(raw["value"])}
+	}
+	panic(decodeError("unknown statement kind %q", kind))
+}
+
+func decodeExpression(data json.RawMessage) Expression {
+	raw, kind := decodeNode(data)
+	switch kind {
+	case "":
+		return nil
+	case "BinaryExpression":
+		return &BinaryExpression{
+			Operator: raw.token("operator"),
+			Left:     raw.expression("left"),
+			Right:    raw.expression("right"),
+		}
+	case "GroupingExpression":
+		return &GroupingExpression{Expression: raw.expression("expression")}
+	case "LiteralExpression":
+		return &LiteralExpression{Token: raw.token("token")}
+	case "UnaryExpression":
+		return &UnaryExpression{Operator: raw.token("operator"), Right: raw.expression("right")}
+	case "VarExpression":
+		return &VarExpression{Name: raw.token("name")}
+	case "AssignExpression":
+		return &AssignExpression{Name: raw.token("name"), Value: raw.expression("value")}
+	case "LogicalExpression":
+		return &LogicalExpression{
+			Operator: raw.token("operator"),
+			Left:     raw.expression("left"),
+			Right:    raw.expression("right"),
+		}
+	case "CallExpression":
+		return &CallExpression{
+			Callee:    raw.expression("callee"),
+			Paren:     raw.token("paren"),
+			Arguments: raw.expressions("arguments"),
+		}
+	case "ListExpression":
+		return &ListExpression{Bracket: raw.position("bracket"), Elements: raw.expressions("elements")}
+	case "IndexExpression":
+		return &IndexExpression{
+			Object:  raw.expression("object"),
+			Bracket: raw.position("bracket"),
+			Index:   raw.expression("index"),
+		}
+	case "SetIndexExpression":
+		return &SetIndexExpression{
+			Object:  raw.expression("object"),
+			Bracket: raw.position("bracket"),
+			Index:   raw.expression("index"),
+			Value:   raw.expression("value"),
+		}
+	case "MapExpression":
+		keys, values := raw.expressions("keys"), raw.expressions("values")
+		if len(keys) != len(values) {
+			panic(decodeError("map has %v keys but %v values", len(keys), len(values)))
+		}
+		return &MapExpression{Brace: raw.position("brace"), Keys: keys, Values: values}
+	case "InterpolationExpression":
+		parts, expressions := raw.tokens("strings"), raw.expressions("expressions")
+		if len(parts) != len(expressions)+1 {
+			panic(decodeError("interpolation has %v strings but %v expressions", len(parts), len(expressions)))
+		}
+		return &InterpolationExpression{Strings: parts, Expressions: expressions}
+	case "GetExpression":
+		return &GetExpression{Object: raw.expression("object"), Name: raw.token("name")}
+	}
+	panic(decodeError("unknown expression kind %q", kind))
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestAstRoundTrip(t *testing.T) {
+	for _, path := range goldenFiles(t) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lox := LoxRunner{ErrorOutput: io.Discard}
+		stmts := lox.Parse(string(data))
+		if lox.HadError {
+			continue
+		}
+
+		encoded, err := MarshalAst(stmts)
+		if err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		decoded, err := UnmarshalAst(encoded)
+		if err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		again, err := MarshalAst(decoded)
+		if err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		if !bytes.Equal(encoded, again) {
+			t.Errorf("%v: decoding and encoding again changed\n%s\ninto\n%s", path, encoded, again)
+		}
+	}
+}
+
+func TestUnmarshalAstErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`[null]`, `statement 0: missing statement`},
+		{`[{"kind": "PrintStatement"}]`, `missing field "expression"`},
+		{`[{"kind": "BlockStatement", "statements": [null]}]`, `field "statements": missing statement`},
+		{`[{"expression": null}]`, `node is missing its kind: {"expression": null}`},
+	}
+	for _, test := range tests {
+		stmts, err := UnmarshalAst([]byte(test.json))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("UnmarshalAst(%v) = %v, %v, want error %v", test.json, stmts, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultMaxDepth is used when Limits.MaxDepth is zero. It keeps deeply
+// nested programs well clear of the Go stack limit.
+const DefaultMaxDepth = 10000
+
+var (
+	ErrStepLimit  = errors.New("step limit exceeded")
+	ErrDepthLimit = errors.New("depth limit exceeded")
+)
+
+// Limits bounds the work a single program may do. A zero MaxSteps or Timeout
+// means that limit is not enforced.
+type Limits struct {
+	// MaxSteps is the number of statements and expressions that may be
+	// evaluated.
+	MaxSteps int
+	// MaxDepth is how deeply execute and evaluate may nest.
+	MaxDepth int
+	// Timeout is the wall time allowed for the whole program.
+	Timeout time.Duration
+}
+
+func (l Limits) maxDepth() int {
+	if l.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return l.MaxDepth
+}
+
+// enter is called on the way into execute and evaluate and panics with a
+// RuntimeError once any limit has been exceeded.
+func (i *Interpreter) enter() {
+	i.steps++
+	if i.Limits.MaxSteps > 0 && i.steps > i.Limits.MaxSteps {
+		panic(i.limitError("Step limit exceeded.", ErrStepLimit))
+	}
+
+	i.depth++
+	if i.depth > i.Limits.maxDepth() {
+		panic(i.limitError("Maximum depth exceeded.", ErrDepthLimit))
+	}
+
+	select {
+	case <-i.ctx.Done():
+		err := i.ctx.Err()
+		message := "Execution cancelled."
+		if errors.Is(err, context.DeadlineExceeded) {
+			message = "Execution timed out."
+		}
+		panic(i.limitError(message, err))
+	default:
+	}
+}
+
+// limitError reports a limit at the line the current frame is executing.
+func (i *Interpreter) limitError(message string, err error) *RuntimeError {
+	line := 0
+	if len(i.frames) > 0 {
+		line = i.frames[len(i.frames)-1].Line
+	}
+	return &RuntimeError{Line: line, Message: message, Err: err}
+}
+
+func (i *Interpreter) leave() {
+	i.depth--
+}
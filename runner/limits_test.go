@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// forever calls itself about 2^50 times, more than any test waits for.
+const forever = `fun f(n) {
+  if (n > 0) {
+    f(n - 1);
+    f(n - 1);
+  }
+}
+f(50);
+`
+
+func TestLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		limits  Limits
+		program string
+		err     error
+		message string
+		line    int
+	}{
+		{"steps", context.Background(), Limits{MaxSteps: 5}, "print 1;\nprint 2;\nprint 3;\n", ErrStepLimit, "Step limit exceeded.", 3},
+		{"depth", context.Background(), Limits{MaxDepth: 100}, "fun f() {\n  f();\n}\nf();\n", ErrDepthLimit, "Maximum depth exceeded.", 2},
+		{"timeout", context.Background(), Limits{Timeout: 10 * time.Millisecond}, forever, context.DeadlineExceeded, "Execution timed out.", 0},
+		{"cancelled", cancelled, Limits{}, "print 1;", context.Canceled, "Execution cancelled.", 1},
+	}
+	for _, test := range tests {
+		r := LoxRunner{Limits: test.limits, Output: io.Discard, ErrorOutput: io.Discard}
+		err := r.Run(test.ctx, test.program)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v: error = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		var runtimeError *RuntimeError
+		if !errors.As(err, &runtimeError) || runtimeError.Message != test.message {
+			t.Errorf("%v: error = %#v, want message %q", test.name, err, test.message)
+		}
+		// A timeout can stop forever on any line of the function.
+		if test.line > 0 && runtimeError.Line != test.line {
+			t.Errorf("%v: line = %v, want %v", test.name, runtimeError.Line, test.line)
+		}
+		if test.line == 0 && (runtimeError.Line < 2 || runtimeError.Line > 4) {
+			t.Errorf("%v: line = %v, want a line in f", test.name, runtimeError.Line)
+		}
+	}
+}
+
+func TestLimitsWithinBounds(t *testing.T) {
+	r := LoxRunner{Limits: Limits{MaxSteps: 1000, MaxDepth: 100, Timeout: time.Second}, Output: io.Discard}
+	if err := r.Run(context.Background(), "fun f(n) { if (n > 0) f(n - 1); }\nf(10);\n"); err != nil {
+		t.Errorf("error = %v, want none", err)
+	}
+}
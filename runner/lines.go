@@ -0,0 +1,176 @@
+package runner
+
+// lineSpan finds the first and last source lines covered by the tokens in a
+// tree. Tokens without a line, such as those in a generated AST, are skipped.
+type lineSpan struct {
+	first int
+	last  int
+}
+
+func statementLines(stmt Statement) (int, int) {
+	span := lineSpan{}
+	span.statement(stmt)
+	return span.first, span.last
+}
+
+func (s *lineSpan) token(token Token) {
+	if token.Line <= 0 {
+		return
+	}
+	if s.first == 0 || token.Line < s.first {
+		s.first = token.Line
+	}
+	if token.Line > s.last {
+		s.last = token.Line
+	}
+}
+
+func (s *lineSpan) statement(stmt Statement) {
+	if stmt != nil {
+		stmt.Accept(s)
+	}
+}
+
+func (s *lineSpan) expression(expr Expression) {
+	if expr != nil {
+		expr.Accept(s)
+	}
+}
+
+func (s *lineSpan) VisitExpressionStatement(es *ExpressionStatement) {
+	s.expression(es.Expression)
+}
+
+func (s *lineSpan) VisitPrintStatement(ps *PrintStatement) {
+	s.token(ps.Keyword)
+	s.expression(ps.Expression)
+}
+
+func (s *lineSpan) VisitVarStatement(vs *VarStatement) {
+	s.token(vs.Name)
+	s.expression(vs.Initializer)
+}
+
+func (s *lineSpan) VisitBlockStatement(bs *BlockStatement) {
+	s.token(bs.LeftBrace)
+	for _, stmt := range bs.Statements {
+		s.statement(stmt)
+	}
+	s.token(bs.RightBrace)
+}
+
+func (s *lineSpan) VisitIfStatement(is *IfStatement) {
+	s.token(is.Keyword)
+	s.expression(is.Condition)
+	s.statement(is.ThenBranch)
+	s.statement(is.ElseBranch)
+}
+
+func (s *lineSpan) VisitFunctionStatement(fs *FunctionStatement) {
+	s.token(fs.Keyword)
+	s.token(fs.Name)
+	s.statement(fs.Body)
+}
+
+func (s *lineSpan) VisitReturnStatement(rs *ReturnStatement) {
+	s.token(rs.Keyword)
+	s.expression(rs.Value)
+}
+
+func (s *lineSpan) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	s.expression(be.Left)
+	s.token(be.Operator)
+	s.expression(be.Right)
+	return nil
+}
+
+func (s *lineSpan) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	s.expression(ge.Expression)
+	return nil
+}
+
+func (s *lineSpan) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	s.token(le.Token)
+	return nil
+}
+
+func (s *lineSpan) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	s.token(ue.Operator)
+	s.expression(ue.Right)
+	return nil
+}
+
+func (s *lineSpan) VisitVarExpression(ve *VarExpression) interface{} {
+	s.token(ve.Name)
+	return nil
+}
+
+func (s *lineSpan) VisitAssignExpression(ae *AssignExpression) interface{} {
+	s.token(ae.Name)
+	s.expression(ae.Value)
+	return nil
+}
+
+func (s *lineSpan) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	s.expression(le.Left)
+	s.token(le.Operator)
+	s.expression(le.Right)
+	return nil
+}
+
+func (s *lineSpan) VisitCallExpression(ce *CallExpression) interface{} {
+	s.expression(ce.Callee)
+	for _, argument := range ce.Arguments {
+		s.expression(argument)
+	}
+	s.token(ce.Paren)
+	return nil
+}
+
+func (s *lineSpan) VisitListExpression(le *ListExpression) interface{} {
+	s.token(le.Bracket)
+	for _, element := range le.Elements {
+		s.expression(element)
+	}
+	return nil
+}
+
+func (s *lineSpan) VisitIndexExpression(ie *IndexExpression) interface{} {
+	s.expression(ie.Object)
+	s.expression(ie.Index)
+	s.token(ie.Bracket)
+	return nil
+}
+
+func (s *lineSpan) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	s.expression(se.Object)
+	s.expression(se.Index)
+	s.token(se.Bracket)
+	s.expression(se.Value)
+	return nil
+}
+
+func (s *lineSpan) VisitMapExpression(me *MapExpression) interface{} {
+	s.token(me.Brace)
+	for index, key := range me.Keys {
+		s.expression(key)
+		s.expression(me.Values[index])
+	}
+	return nil
+}
+
+func (s *lineSpan) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	for index, part := range ie.Strings {
+		s.token(part)
+		if index < len(ie.Expressions) {
+			s.expression(ie.Expressions[index])
+		}
+	}
+	return nil
+}
+
+func (s *lineSpan) VisitGetExpression(ge *GetExpression) interface{} {
+	s.expression(ge.Object)
+	s.token(ge.Name)
+	return nil
+}
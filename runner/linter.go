@@ -0,0 +1,310 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Lint checks, named as they are enabled and disabled from the command line.
+const (
+	CheckUnused          = "unused"
+	CheckShadow          = "shadow"
+	CheckSelfAssign      = "self-assign"
+	CheckConstantCompare = "constant-compare"
+	CheckEmptyBlock      = "empty-block"
+)
+
+// LintChecks describes every check the Linter knows about.
+var LintChecks = map[string]string{
+	CheckUnused:          "local variables that are never read",
+	CheckShadow:          "local variables that hide a variable from an enclosing scope",
+	CheckSelfAssign:      "assignments of a variable to itself",
+	CheckConstantCompare: "comparisons between literals that are always true or false",
+	CheckEmptyBlock:      "blocks with no statements",
+}
+
+type LintFinding struct {
+	Check   string `json:"check"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// Linter walks parsed statements looking for likely mistakes. Checks named
+// in Disabled are skipped.
+type Linter struct {
+	Disabled map[string]bool
+
+	findings []LintFinding
+	scopes   []map[string]*lintVariable
+}
+
+type lintVariable struct {
+	name Token
+	used bool
+}
+
+func (l *Linter) Lint(stmts []Statement) []LintFinding {
+	l.findings = make([]LintFinding, 0)
+	l.scopes = []map[string]*lintVariable{make(map[string]*lintVariable)}
+	for _, stmt := range stmts {
+		l.statement(stmt)
+	}
+
+	sort.SliceStable(l.findings, func(a, b int) bool {
+		if l.findings[a].Line != l.findings[b].Line {
+			return l.findings[a].Line < l.findings[b].Line
+		}
+		return l.findings[a].Column < l.findings[b].Column
+	})
+	return l.findings
+}
+
+func (l *Linter) report(check string, token Token, format string, args ...interface{}) {
+	if l.Disabled[check] {
+		return
+	}
+	l.findings = append(l.findings, LintFinding{
+		Check:   check,
+		Line:    token.Line,
+		Column:  token.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *Linter) statement(stmt Statement) {
+	if stmt != nil {
+		stmt.Accept(l)
+	}
+}
+
+func (l *Linter) expression(expr Expression) {
+	if expr != nil {
+		expr.Accept(l)
+	}
+}
+
+func (l *Linter) beginScope() {
+	l.scopes = append(l.scopes, make(map[string]*lintVariable))
+}
+
+func (l *Linter) endScope() {
+	scope := l.scopes[len(l.scopes)-1]
+	l.scopes = l.scopes[:len(l.scopes)-1]
+
+	for _, variable := range scope {
+		if !variable.used {
+			l.report(CheckUnused, variable.name, "'%v' is declared but never used.", variable.name.Lexeme)
+		}
+	}
+}
+
+func (l *Linter) declare(name Token) {
+	scope := l.scopes[len(l.scopes)-1]
+	if len(l.scopes) > 1 {
+		for i := len(l.scopes) - 2; i >= 0; i-- {
+			if outer, ok := l.scopes[i][name.Lexeme]; ok {
+				l.report(CheckShadow, name, "'%v' shadows the variable declared on line %v.", name.Lexeme, outer.name.Line)
+				break
+			}
+		}
+	}
+	scope[name.Lexeme] = &lintVariable{name: name}
+}
+
+func (l *Linter) use(name Token) {
+	for i := len(l.scopes) - 1; i >= 0; i-- {
+		if variable, ok := l.scopes[i][name.Lexeme]; ok {
+			variable.used = true
+			return
+		}
+	}
+}
+
+func (l *Linter) VisitExpressionStatement(es *ExpressionStatement) {
+	l.expression(es.Expression)
+}
+
+func (l *Linter) VisitPrintStatement(ps *PrintStatement) {
+	l.expression(ps.Expression)
+}
+
+func (l *Linter) VisitVarStatement(vs *VarStatement) {
+	l.expression(vs.Initializer)
+	l.declare(vs.Name)
+}
+
+func (l *Linter) VisitBlockStatement(bs *BlockStatement) {
+	if len(bs.Statements) == 0 {
+		l.report(CheckEmptyBlock, bs.LeftBrace, "Empty block.")
+	}
+
+	l.beginScope()
+	for _, stmt := range bs.Statements {
+		l.statement(stmt)
+	}
+	l.endScope()
+}
+
+func (l *Linter) VisitIfStatement(is *IfStatement) {
+	l.expression(is.Condition)
+	l.statement(is.ThenBranch)
+	l.statement(is.ElseBranch)
+}
+
+func (l *Linter) VisitFunctionStatement(fs *FunctionStatement) {
+	l.declare(fs.Name)
+	l.use(fs.Name)
+
+	l.beginScope()
+	for _, param := range fs.Params {
+		l.declare(param)
+		l.use(param)
+	}
+	for _, stmt := range fs.Body.Statements {
+		l.statement(stmt)
+	}
+	l.endScope()
+}
+
+func (l *Linter) VisitReturnStatement(rs *ReturnStatement) {
+	l.expression(rs.Value)
+}
+
+func (l *Linter) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	l.expression(be.Left)
+	l.expression(be.Right)
+
+	if result, ok := constantComparison(be); ok {
+		l.report(CheckConstantCompare, be.Operator, "Comparison is always %v.", result)
+	}
+	return nil
+}
+
+func (l *Linter) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	l.expression(ge.Expression)
+	return nil
+}
+
+func (l *Linter) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	return nil
+}
+
+func (l *Linter) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	l.expression(ue.Right)
+	return nil
+}
+
+func (l *Linter) VisitVarExpression(ve *VarExpression) interface{} {
+	l.use(ve.Name)
+	return nil
+}
+
+func (l *Linter) VisitAssignExpression(ae *AssignExpression) interface{} {
+	l.expression(ae.Value)
+
+	if value, ok := ungroup(ae.Value).(*VarExpression); ok && value.Name.Lexeme == ae.Name.Lexeme {
+		l.report(CheckSelfAssign, ae.Name, "'%v' is assigned to itself.", ae.Name.Lexeme)
+	}
+	return nil
+}
+
+func (l *Linter) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	l.expression(le.Left)
+	l.expression(le.Right)
+	return nil
+}
+
+func (l *Linter) VisitCallExpression(ce *CallExpression) interface{} {
+	l.expression(ce.Callee)
+	for _, argument := range ce.Arguments {
+		l.expression(argument)
+	}
+	return nil
+}
+
+func (l *Linter) VisitListExpression(le *ListExpression) interface{} {
+	for _, element := range le.Elements {
+		l.expression(element)
+	}
+	return nil
+}
+
+func (l *Linter) VisitIndexExpression(ie *IndexExpression) interface{} {
+	l.expression(ie.Object)
+	l.expression(ie.Index)
+	return nil
+}
+
+func (l *Linter) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	l.expression(se.Object)
+	l.expression(se.Index)
+	l.expression(se.Value)
+	return nil
+}
+
+func (l *Linter) VisitMapExpression(me *MapExpression) interface{} {
+	for index, key := range me.Keys {
+		l.expression(key)
+		l.expression(me.Values[index])
+	}
+	return nil
+}
+
+func (l *Linter) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	for _, expr := range ie.Expressions {
+		l.expression(expr)
+	}
+	return nil
+}
+
+func (l *Linter) VisitGetExpression(ge *GetExpression) interface{} {
+	l.expression(ge.Object)
+	return nil
+}
+
+func ungroup(expr Expression) Expression {
+	for {
+		group, ok := expr.(*GroupingExpression)
+		if !ok {
+			return expr
+		}
+		expr = group.Expression
+	}
+}
+
+// constantComparison works out the result of a comparison whose operands are
+// both literals. Orderings between anything but numbers are runtime errors
+// rather than constants, so they aren't reported.
+func constantComparison(be *BinaryExpression) (bool, bool) {
+	left, leftOk := ungroup(be.Left).(*LiteralExpression)
+	right, rightOk := ungroup(be.Right).(*LiteralExpression)
+	if !leftOk || !rightOk {
+		return false, false
+	}
+	leftValue, rightValue := left.Token.Value, right.Token.Value
+
+	switch be.Operator.Type {
+	case EQUAL_EQUAL:
+		return leftValue == rightValue, true
+	case BANG_EQUAL:
+		return leftValue != rightValue, true
+	}
+
+	leftNum, leftOk := leftValue.(float64)
+	rightNum, rightOk := rightValue.(float64)
+	if !leftOk || !rightOk {
+		return false, false
+	}
+	switch be.Operator.Type {
+	case GREATER:
+		return leftNum > rightNum, true
+	case GREATER_EQUAL:
+		return leftNum >= rightNum, true
+	case LESS:
+		return leftNum < rightNum, true
+	case LESS_EQUAL:
+		return leftNum <= rightNum, true
+	}
+	return false, false
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func lint(t *testing.T, disabled map[string]bool, source string) []LintFinding {
+	t.Helper()
+	lox := LoxRunner{ErrorOutput: io.Discard}
+	stmts := lox.Parse(source)
+	if lox.HadError {
+		t.Fatalf("%q doesn't parse: %v", source, lox.Errors)
+	}
+	linter := Linter{Disabled: disabled}
+	return linter.Lint(stmts)
+}
+
+func TestLinter(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []LintFinding
+	}{
+		{
+			"{ var a = 1; var b = 2; print b; }",
+			[]LintFinding{{CheckUnused, 1, 7, "'a' is declared but never used."}},
+		},
+		{
+			"var a = 1; { var a = 2; print a; }",
+			[]LintFinding{{CheckShadow, 1, 18, "'a' shadows the variable declared on line 1."}},
+		},
+		{
+			"var a = 1; a = a;",
+			[]LintFinding{{CheckSelfAssign, 1, 12, "'a' is assigned to itself."}},
+		},
+		{
+			"print 1 == 2; print \"a\" != \"a\";",
+			[]LintFinding{
+				{CheckConstantCompare, 1, 9, "Comparison is always false."},
+				{CheckConstantCompare, 1, 25, "Comparison is always false."},
+			},
+		},
+		{
+			"if (true) {} else { print 1; }",
+			[]LintFinding{{CheckEmptyBlock, 1, 11, "Empty block."}},
+		},
+		{
+			"var a = 1; { var b = a; print b; } fun f(x) { return x; }",
+			[]LintFinding{},
+		},
+	}
+	for _, test := range tests {
+		got := lint(t, nil, test.source)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Lint(%q) =\n%+v\nwant\n%+v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestLinterDisabled(t *testing.T) {
+	source := "var a = 1; { var a = 2; a = a; {} }"
+	if got := lint(t, nil, source); len(got) != 3 {
+		t.Fatalf("Lint(%q) = %+v, want 3 findings", source, got)
+	}
+
+	got := lint(t, map[string]bool{CheckShadow: true, CheckEmptyBlock: true}, source)
+	want := []LintFinding{{CheckSelfAssign, 1, 25, "'a' is assigned to itself."}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with shadow and empty-block disabled, Lint(%q) = %+v, want %+v", source, got, want)
+	}
+}
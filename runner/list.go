@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoxList is a list value. Lists are mutable and shared by reference, so a
+// list changed through one variable is changed for every other.
+type LoxList struct {
+	Elements []interface{}
+}
+
+func NewList(elements []interface{}) *LoxList {
+	return &LoxList{Elements: elements}
+}
+
+func (l *LoxList) String() string {
+	var builder strings.Builder
+	writeValue(&builder, l, make(map[interface{}]bool))
+	return builder.String()
+}
+
+// writeValue writes a value as it appears inside a collection: strings are
+// quoted, and a collection that contains itself is shown as [...] or {...}.
+func writeValue(builder *strings.Builder, value interface{}, seen map[interface{}]bool) {
+	switch collection := value.(type) {
+	case *LoxList:
+		if seen[collection] {
+			builder.WriteString("[...]")
+			return
+		}
+		seen[collection] = true
+		defer delete(seen, collection)
+
+		builder.WriteString("[")
+		for index, element := range collection.Elements {
+			if index > 0 {
+				builder.WriteString(", ")
+			}
+			writeValue(builder, element, seen)
+		}
+		builder.WriteString("]")
+	case *LoxMap:
+		if seen[collection] {
+			builder.WriteString("{...}")
+			return
+		}
+		seen[collection] = true
+		defer delete(seen, collection)
+
+		builder.WriteString("{")
+		for index, key := range collection.keys {
+			if index > 0 {
+				builder.WriteString(", ")
+			}
+			writeValue(builder, key, seen)
+			builder.WriteString(": ")
+			writeValue(builder, collection.values[key], seen)
+		}
+		builder.WriteString("}")
+	default:
+		builder.WriteString(describe(value))
+	}
+}
+
+// index resolves a Lox index into the list, counting negative indexes back
+// from the end. When end is true the index may also be one past the last
+// element, as when inserting.
+func (l *LoxList) index(value interface{}, end bool) (int, error) {
+	return sequenceIndex("List", value, len(l.Elements), end)
+}
+
+// sequenceIndex resolves an index into a list or string of the given length.
+// kind names the sequence in errors.
+func sequenceIndex(kind string, value interface{}, length int, end bool) (int, error) {
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, &RuntimeError{Message: fmt.Sprintf("%v index must be an integer.", kind)}
+	}
+	if number < 0 {
+		number += float64(length)
+	}
+	if number < 0 || number > float64(length) || (number == float64(length) && !end) {
+		return 0, &RuntimeError{Message: fmt.Sprintf("%v index %v out of range for %v of length %v.", kind, Stringify(value), strings.ToLower(kind), length)}
+	}
+	return int(number), nil
+}
+
+// bound resolves a slice bound, counting negative bounds back from the end
+// and clamping to the list.
+func bound(value float64, length int) int {
+	if value < 0 {
+		value += float64(length)
+	}
+	return int(math.Min(math.Max(value, 0), float64(length)))
+}
+
+func init() {
+	Natives.Register(&NativeFunction{
+		Name:   "len",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			switch value := arguments[0].(type) {
+			case *LoxList:
+				return float64(len(value.Elements)), nil
+			case *LoxMap:
+				return float64(value.Len()), nil
+			case string:
+				return float64(utf8.RuneCountInString(value)), nil
+			}
+			return nil, argumentError("len", arguments, 0, "list, map or string")
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "push",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			list, err := argument[*LoxList]("push", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			list.Elements = append(list.Elements, arguments[1])
+			return float64(len(list.Elements)), nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "pop",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			list, err := argument[*LoxList]("pop", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if len(list.Elements) == 0 {
+				return nil, &RuntimeError{Message: "Can't pop from an empty list."}
+			}
+			last := list.Elements[len(list.Elements)-1]
+			list.Elements = list.Elements[:len(list.Elements)-1]
+			return last, nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "insert",
+		Params: 3,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			list, err := argument[*LoxList]("insert", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			index, err := list.index(arguments[1], true)
+			if err != nil {
+				return nil, err
+			}
+			list.Elements = append(list.Elements, nil)
+			copy(list.Elements[index+1:], list.Elements[index:])
+			list.Elements[index] = arguments[2]
+			return float64(len(list.Elements)), nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "slice",
+		Params: -1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if err := checkArgumentCount("slice", arguments, 2, 3); err != nil {
+				return nil, err
+			}
+			var length int
+			switch sequence := arguments[0].(type) {
+			case *LoxList:
+				length = len(sequence.Elements)
+			case string:
+				length = utf8.RuneCountInString(sequence)
+			default:
+				return nil, argumentError("slice", arguments, 0, "list or string")
+			}
+			start, err := integerArgument("slice", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			end := float64(length)
+			if len(arguments) > 2 {
+				if end, err = integerArgument("slice", arguments, 2); err != nil {
+					return nil, err
+				}
+			}
+
+			from, to := bound(start, length), bound(end, length)
+			if to < from {
+				to = from
+			}
+			if list, ok := arguments[0].(*LoxList); ok {
+				return NewList(append([]interface{}{}, list.Elements[from:to]...)), nil
+			}
+			return string([]rune(arguments[0].(string))[from:to]), nil
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LoxMap is a map value. Keys may be numbers, strings, booleans or nil, and
+// are kept in the order they were first inserted. Like lists, maps are
+// shared by reference.
+type LoxMap struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+func NewMap() *LoxMap {
+	return &LoxMap{values: make(map[interface{}]interface{})}
+}
+
+// mapKey checks that key can be hashed. Zero and negative zero are the
+// same key, and NaN, which equals nothing, isn't allowed.
+func mapKey(key interface{}) (interface{}, error) {
+	switch value := key.(type) {
+	case nil, bool, string:
+		return key, nil
+	case float64:
+		if math.IsNaN(value) {
+			return nil, &RuntimeError{Message: "Map key can't be NaN."}
+		}
+		if value == 0 {
+			return 0.0, nil
+		}
+		return key, nil
+	}
+	return nil, &RuntimeError{Message: fmt.Sprintf("Map keys must be numbers, strings, booleans or nil, not %v.", TypeName(key))}
+}
+
+func (m *LoxMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *LoxMap) Get(key interface{}) (interface{}, bool, error) {
+	key, err := mapKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := m.values[key]
+	return value, ok, nil
+}
+
+func (m *LoxMap) Set(key interface{}, value interface{}) error {
+	key, err := mapKey(key)
+	if err != nil {
+		return err
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+	return nil
+}
+
+// Delete removes key, reporting whether it was there.
+func (m *LoxMap) Delete(key interface{}) (bool, error) {
+	key, err := mapKey(key)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := m.values[key]; !ok {
+		return false, nil
+	}
+	delete(m.values, key)
+	for index, existing := range m.keys {
+		if existing == key {
+			m.keys = append(m.keys[:index], m.keys[index+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+// Keys returns the keys in insertion order.
+func (m *LoxMap) Keys() []interface{} {
+	return append([]interface{}(nil), m.keys...)
+}
+
+func (m *LoxMap) String() string {
+	var builder strings.Builder
+	writeValue(&builder, m, make(map[interface{}]bool))
+	return builder.String()
+}
+
+// lookup returns the value at key, failing when it isn't there.
+func (m *LoxMap) lookup(key interface{}) (interface{}, error) {
+	value, ok, err := m.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Undefined key %v.", describe(key))}
+	}
+	return value, nil
+}
+
+func init() {
+	Natives.Register(&NativeFunction{
+		Name:   "has",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			m, err := argument[*LoxMap]("has", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			_, ok, err := m.Get(arguments[1])
+			return ok, err
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "delete",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			m, err := argument[*LoxMap]("delete", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return m.Delete(arguments[1])
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "keys",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			m, err := argument[*LoxMap]("keys", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return NewList(m.Keys()), nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "values",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			m, err := argument[*LoxMap]("values", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			values := make([]interface{}, 0, m.Len())
+			for _, key := range m.keys {
+				values = append(values, m.values[key])
+			}
+			return NewList(values), nil
+		},
+	})
+}
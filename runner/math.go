@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// The math namespace holds numeric functions and constants, reached as
+// math.sqrt(2):
+//
+//	sqrt(x), pow(x, y), abs(x), exp(x), log(x)   log is the natural log
+//	sin(x), cos(x), tan(x)                       angles in radians
+//	min(x, ...), max(x, ...)                     of one or more numbers
+//	random()                                     a number in [0, 1)
+//	randomInt(low, high)                         an integer in [low, high]
+//	seed(n)                                      restarts random numbers
+//	pi, e, inf, nan
+//
+// Each interpreter has its own random numbers, seeded from the clock until
+// a program calls math.seed.
+
+// registerMath registers a math native of fixed arity taking numbers.
+func registerMath(name string, params int, fn func(arguments []float64) float64) {
+	qualified := "math." + name
+	Natives.RegisterIn("math", name, &NativeFunction{
+		Name:   qualified,
+		Params: params,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			numbers, err := numberArguments(qualified, arguments)
+			if err != nil {
+				return nil, err
+			}
+			return fn(numbers), nil
+		},
+	})
+}
+
+func numberArguments(name string, arguments []interface{}) ([]float64, error) {
+	numbers := make([]float64, len(arguments))
+	for index := range arguments {
+		number, err := argument[float64](name, arguments, index)
+		if err != nil {
+			return nil, err
+		}
+		numbers[index] = number
+	}
+	return numbers, nil
+}
+
+// registerExtreme registers math.min or math.max, which take any number of
+// arguments but at least one.
+func registerExtreme(name string, pick func(float64, float64) float64) {
+	qualified := "math." + name
+	Natives.RegisterIn("math", name, &NativeFunction{
+		Name:   qualified,
+		Params: -1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(arguments) == 0 {
+				return nil, &RuntimeError{Message: fmt.Sprintf("Expected at least 1 argument to %v but got 0.", qualified)}
+			}
+			numbers, err := numberArguments(qualified, arguments)
+			if err != nil {
+				return nil, err
+			}
+			result := numbers[0]
+			for _, number := range numbers[1:] {
+				result = pick(result, number)
+			}
+			return result, nil
+		},
+	})
+}
+
+// rand returns the interpreter's random number source.
+func (i *Interpreter) rand() *rand.Rand {
+	if i.random == nil {
+		i.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return i.random
+}
+
+func init() {
+	registerMath("sqrt", 1, func(x []float64) float64 { return math.Sqrt(x[0]) })
+	registerMath("pow", 2, func(x []float64) float64 { return math.Pow(x[0], x[1]) })
+	registerMath("abs", 1, func(x []float64) float64 { return math.Abs(x[0]) })
+	registerMath("exp", 1, func(x []float64) float64 { return math.Exp(x[0]) })
+	registerMath("log", 1, func(x []float64) float64 { return math.Log(x[0]) })
+	registerMath("sin", 1, func(x []float64) float64 { return math.Sin(x[0]) })
+	registerMath("cos", 1, func(x []float64) float64 { return math.Cos(x[0]) })
+	registerMath("tan", 1, func(x []float64) float64 { return math.Tan(x[0]) })
+	registerExtreme("min", math.Min)
+	registerExtreme("max", math.Max)
+
+	Natives.RegisterIn("math", "pi", math.Pi)
+	Natives.RegisterIn("math", "e", math.E)
+	Natives.RegisterIn("math", "inf", math.Inf(1))
+	Natives.RegisterIn("math", "nan", math.NaN())
+
+	Natives.RegisterIn("math", "random", &NativeFunction{
+		Name:   "math.random",
+		Params: 0,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return interpreter.rand().Float64(), nil
+		},
+	})
+
+	Natives.RegisterIn("math", "randomInt", &NativeFunction{
+		Name:   "math.randomInt",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			low, err := integerArgument("math.randomInt", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			high, err := integerArgument("math.randomInt", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if high < low {
+				return nil, invalidArgument("math.randomInt", 1, "at least argument 1")
+			}
+			if high-low >= math.MaxInt64 {
+				return nil, invalidArgument("math.randomInt", 1, "less than 2^63 above argument 1")
+			}
+			return low + float64(interpreter.rand().Int63n(int64(high-low)+1)), nil
+		},
+	})
+
+	Natives.RegisterIn("math", "seed", &NativeFunction{
+		Name:   "math.seed",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			seed, err := integerArgument("math.seed", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			interpreter.random = rand.New(rand.NewSource(int64(seed)))
+			return nil, nil
+		},
+	})
+}
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NativeRegistry holds the natives defined in every new interpreter's global
+// environment.
+type NativeRegistry struct {
+	natives    map[string]*NativeFunction
+	namespaces map[string]map[string]interface{}
+}
+
+func NewNativeRegistry() *NativeRegistry {
+	return &NativeRegistry{
+		natives:    make(map[string]*NativeFunction),
+		namespaces: make(map[string]map[string]interface{}),
+	}
+}
+
+// Natives is the registry used by NewInterpreter. Embedders may register
+// their own natives here before creating interpreters.
+var Natives = NewNativeRegistry()
+
+func (r *NativeRegistry) Register(native *NativeFunction) {
+	r.natives[native.Name] = native
+}
+
+// RegisterIn adds a value, usually a native, to a namespace: a map defined
+// as a global that programs reach its members through, as in math.sqrt(2).
+func (r *NativeRegistry) RegisterIn(namespace string, name string, value interface{}) {
+	members, ok := r.namespaces[namespace]
+	if !ok {
+		members = make(map[string]interface{})
+		r.namespaces[namespace] = members
+	}
+	members[name] = value
+}
+
+// Defines reports whether name is a native or a namespace.
+func (r *NativeRegistry) Defines(name string) bool {
+	_, native := r.natives[name]
+	_, namespace := r.namespaces[name]
+	return native || namespace
+}
+
+func (r *NativeRegistry) Lookup(name string) (*NativeFunction, bool) {
+	native, ok := r.natives[name]
+	return native, ok
+}
+
+// Names returns the registered native and namespace names in sorted order.
+func (r *NativeRegistry) Names() []string {
+	names := make([]string, 0, len(r.natives)+len(r.namespaces))
+	for name := range r.natives {
+		names = append(names, name)
+	}
+	for name := range r.namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// define defines the natives in env, with a new map for each namespace so
+// that programs changing one don't affect other interpreters.
+func (r *NativeRegistry) define(env *Environment) {
+	for name, native := range r.natives {
+		env.Define(name, native)
+	}
+	for namespace, members := range r.namespaces {
+		names := make([]string, 0, len(members))
+		for name := range members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		m := NewMap()
+		for _, name := range names {
+			m.Set(name, members[name])
+		}
+		env.Define(namespace, m)
+	}
+}
+
+// argument returns the argument at index as a T, or an error naming the
+// native and the type it expected.
+func argument[T any](name string, arguments []interface{}, index int) (T, error) {
+	value, ok := arguments[index].(T)
+	if !ok {
+		var zero T
+		return zero, argumentError(name, arguments, index, TypeName(zero))
+	}
+	return value, nil
+}
+
+// integerArgument returns the argument at index, which must be a whole
+// number.
+func integerArgument(name string, arguments []interface{}, index int) (float64, error) {
+	number, ok := arguments[index].(float64)
+	if !ok {
+		return 0, argumentError(name, arguments, index, "integer")
+	}
+	if !isInteger(number) {
+		return 0, invalidArgument(name, index, "an integer, not %v", Stringify(number))
+	}
+	return number, nil
+}
+
+func argumentError(name string, arguments []interface{}, index int, expected string) error {
+	article := "a"
+	if strings.ContainsRune("aeiou", rune(expected[0])) {
+		article = "an"
+	}
+	return invalidArgument(name, index, "%v %v, not %v", article, expected, TypeName(arguments[index]))
+}
+
+// invalidArgument builds the error for an argument to a native that isn't
+// what it should be.
+func invalidArgument(name string, index int, format string, args ...interface{}) error {
+	return &RuntimeError{Message: fmt.Sprintf("Argument %v to %v must be %v.", index+1, name, fmt.Sprintf(format, args...))}
+}
+
+func init() {
+	Natives.Register(&NativeFunction{
+		Name:   "clock",
+		Params: 0,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return float64(time.Now().UnixNano()) / float64(time.Second), nil
+		},
+	})
+}
@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	errNumberSyntax = errors.New("Invalid number literal.")
+	errNumberRange  = errors.New("Number literal is out of range.")
+)
+
+// numberLiteral matches the number literals the scanner accepts.
+var numberLiteral = regexp.MustCompile(`^(0[xX][0-9a-fA-F_]+|0[bB][01_]+|[0-9][0-9_]*(\.[0-9][0-9_]*)?([eE][+-]?[0-9][0-9_]*)?)$`)
+
+// parseNumber converts a number literal to its value. Underscores must sit
+// between digits.
+func parseNumber(text string) (float64, error) {
+	if len(text) > 1 && text[0] == '0' && strings.ContainsRune("xXbB", rune(text[1])) {
+		integer, ok := new(big.Int).SetString(text, 0)
+		if !ok {
+			return 0, errNumberSyntax
+		}
+		value, _ := new(big.Float).SetInt(integer).Float64()
+		if math.IsInf(value, 0) {
+			return value, errNumberRange
+		}
+		return value, nil
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return value, errNumberRange
+	}
+	if err != nil {
+		return 0, errNumberSyntax
+	}
+	return value, nil
+}
+
+// formatNumber writes a number the way print shows it: in full between
+// 1e-7 and 1e21, as most numbers in programs are, and in exponent form
+// beyond. Infinities are inf and -inf, NaN is nan, and negative zero keeps
+// its sign.
+func formatNumber(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	case math.IsNaN(value):
+		return "nan"
+	}
+	magnitude := math.Abs(value)
+	if magnitude == 0 || (magnitude >= 1e-7 && magnitude < 1e21) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	return strings.NewReplacer("e-0", "e-", "e+0", "e+").Replace(text)
+}
+
+// isInteger reports whether value is a finite whole number.
+func isInteger(value float64) bool {
+	return value == math.Trunc(value) && !math.IsInf(value, 0)
+}
+
+// registerRounding registers a native rounding its number argument with fn.
+func registerRounding(name string, fn func(float64) float64) {
+	Natives.Register(&NativeFunction{
+		Name:   name,
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			number, err := argument[float64](name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return fn(number), nil
+		},
+	})
+}
+
+func init() {
+	// num parses a number written as a Lox literal, optionally signed and
+	// surrounded by whitespace. It returns nil for anything else.
+	Natives.Register(&NativeFunction{
+		Name:   "num",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			text, err := argument[string]("num", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			text = strings.TrimSpace(text)
+			sign := 1.0
+			if strings.HasPrefix(text, "-") {
+				sign, text = -1, text[1:]
+			} else {
+				text = strings.TrimPrefix(text, "+")
+			}
+			if !numberLiteral.MatchString(text) {
+				return nil, nil
+			}
+			value, err := parseNumber(text)
+			if err != nil {
+				return nil, nil
+			}
+			return sign * value, nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "str",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return Stringify(arguments[0]), nil
+		},
+	})
+
+	// format writes a number with a fixed number of digits after the
+	// decimal point.
+	Natives.Register(&NativeFunction{
+		Name:   "format",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			number, err := argument[float64]("format", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			precision, err := integerArgument("format", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if precision < 0 || precision > 100 {
+				return nil, invalidArgument("format", 1, "between 0 and 100")
+			}
+			if math.IsInf(number, 0) || math.IsNaN(number) {
+				return formatNumber(number), nil
+			}
+			return strconv.FormatFloat(number, 'f', int(precision), 64), nil
+		},
+	})
+
+	registerRounding("floor", math.Floor)
+	registerRounding("ceil", math.Ceil)
+	registerRounding("round", math.Round)
+	registerRounding("trunc", math.Trunc)
+
+	Natives.Register(&NativeFunction{
+		Name:   "isInteger",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			number, ok := arguments[0].(float64)
+			return ok && isInteger(number), nil
+		},
+	})
+}
@@ -1,14 +1,14 @@
 package runner
 
-import (
-	"errors"
-	"fmt"
-)
+import "strings"
 
 type Parser struct {
 	Tokens  []Token
 	current int
 	Runner  *LoxRunner
+	// functions counts the function bodies being parsed, to catch returns
+	// outside of any.
+	functions int
 }
 
 func NewParser(tokens []Token, runner *LoxRunner) *Parser {
@@ -35,8 +35,16 @@ func (p *Parser) assignment() Expression {
 				Value: value,
 			}
 		}
+		if index, ok := expr.(*IndexExpression); ok {
+			return &SetIndexExpression{
+				Object:  index.Object,
+				Bracket: index.Bracket,
+				Index:   index.Index,
+				Value:   value,
+			}
+		}
 
-		p.error(equals, "Invalid assignment target")
+		p.error(equals, "Invalid assignment target.")
 	}
 
 	return expr
@@ -46,10 +54,11 @@ func (p *Parser) or() Expression {
 	expr := p.and()
 
 	for p.match(OR) {
+		operator := p.previous()
 		expr = &LogicalExpression{
 			Left:     expr,
 			Right:    p.and(),
-			Operator: p.previous(),
+			Operator: operator,
 		}
 	}
 
@@ -60,10 +69,11 @@ func (p *Parser) and() Expression {
 	expr := p.equality()
 
 	for p.match(AND) {
+		operator := p.previous()
 		expr = &LogicalExpression{
 			Left:     expr,
 			Right:    p.equality(),
-			Operator: p.previous(),
+			Operator: operator,
 		}
 	}
 
@@ -115,7 +125,7 @@ func (p *Parser) term() Expression {
 func (p *Parser) factor() Expression {
 	expr := p.unary()
 
-	for p.match(SLASH, STAR) {
+	for p.match(SLASH, STAR, PERCENT) {
 		expr = &BinaryExpression{
 			Operator: p.previous(),
 			Left:     expr,
@@ -133,7 +143,75 @@ func (p *Parser) unary() Expression {
 			Right:    p.unary(),
 		}
 	}
-	return p.primary()
+	return p.power()
+}
+
+// power parses '**', which binds tighter than a unary operator on its left
+// but not on its right, and groups to the right: -2 ** 2 is -(2 ** 2) and
+// 2 ** -1 is 2 ** (-1).
+func (p *Parser) power() Expression {
+	expr := p.call()
+
+	if p.match(STAR_STAR) {
+		return &BinaryExpression{
+			Operator: p.previous(),
+			Left:     expr,
+			Right:    p.unary(),
+		}
+	}
+
+	return expr
+}
+
+func (p *Parser) call() Expression {
+	expr := p.primary()
+
+	for {
+		if p.match(LEFT_PAREN) {
+			expr = p.finishCall(expr)
+		} else if p.match(DOT) {
+			name := p.consume(IDENTIFIER, "Expect property name after '.'.")
+			expr = &GetExpression{
+				Object: expr,
+				Name:   name,
+			}
+		} else if p.match(LEFT_BRACKET) {
+			index := p.expression()
+			bracket := p.consume(RIGHT_BRACKET, "Expect ']' after index.")
+			expr = &IndexExpression{
+				Object:  expr,
+				Bracket: bracket,
+				Index:   index,
+			}
+		} else {
+			break
+		}
+	}
+
+	return expr
+}
+
+func (p *Parser) finishCall(callee Expression) Expression {
+	arguments := make([]Expression, 0)
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(arguments) >= 255 {
+				p.error(p.peek(), "Can't have more than 255 arguments.")
+			}
+			arguments = append(arguments, p.expression())
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	paren := p.consume(RIGHT_PAREN, "Expect ')' after arguments.")
+
+	return &CallExpression{
+		Callee:    callee,
+		Paren:     paren,
+		Arguments: arguments,
+	}
 }
 
 func (p *Parser) primary() Expression {
@@ -157,7 +235,86 @@ func (p *Parser) primary() Expression {
 		}
 	}
 
-	panic(p.error(p.peek(), "Unexpected token"))
+	if p.match(INTERPOLATION) {
+		return p.interpolation()
+	}
+
+	if p.match(LEFT_BRACKET) {
+		return p.list()
+	}
+
+	// A brace starting a statement opens a block, so a map literal is only
+	// ever seen here, in expression position.
+	if p.match(LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+
+	panic(p.error(p.peek(), "Expect expression."))
+}
+
+func (p *Parser) interpolation() Expression {
+	parts := []Token{p.previous()}
+	expressions := make([]Expression, 0)
+	for {
+		if p.continuesString() {
+			panic(p.error(p.peek(), "Expect expression."))
+		}
+		expressions = append(expressions, p.expression())
+		if !p.match(INTERPOLATION) {
+			break
+		}
+		parts = append(parts, p.previous())
+	}
+	parts = append(parts, p.consume(STRING, "Expect '}' after interpolated expression."))
+
+	return &InterpolationExpression{
+		Strings:     parts,
+		Expressions: expressions,
+	}
+}
+
+// continuesString reports whether the next token is the rest of a string
+// after an interpolated expression, rather than the start of one.
+func (p *Parser) continuesString() bool {
+	return (p.check(STRING) || p.check(INTERPOLATION)) && strings.HasPrefix(p.peek().Lexeme, "}")
+}
+
+func (p *Parser) list() Expression {
+	bracket := p.previous()
+	elements := make([]Expression, 0)
+	for !p.check(RIGHT_BRACKET) && !p.isAtEnd() {
+		elements = append(elements, p.expression())
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACKET, "Expect ']' after list elements.")
+
+	return &ListExpression{
+		Bracket:  bracket,
+		Elements: elements,
+	}
+}
+
+func (p *Parser) mapLiteral() Expression {
+	brace := p.previous()
+	keys := make([]Expression, 0)
+	values := make([]Expression, 0)
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		keys = append(keys, p.expression())
+		p.consume(COLON, "Expect ':' after map key.")
+		values = append(values, p.expression())
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACE, "Expect '}' after map entries.")
+
+	return &MapExpression{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}
 }
 
 func (p *Parser) consume(ttype TokenType, message string) Token {
@@ -168,9 +325,17 @@ func (p *Parser) consume(ttype TokenType, message string) Token {
 	panic(p.error(p.peek(), message))
 }
 
+// parseError unwinds the parser to the nearest declaration after an error
+// has been reported.
+type parseError string
+
+func (e parseError) Error() string {
+	return string(e)
+}
+
 func (p *Parser) error(token Token, message string) error {
 	p.Runner.tokenError(token, message)
-	return errors.New(message)
+	return parseError(message)
 }
 
 func (p *Parser) match(checks ...TokenType) bool {
@@ -210,11 +375,6 @@ func (p *Parser) peek() Token {
 }
 
 func (p *Parser) parse() []Statement {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println(r)
-		}
-	}()
 	statements := make([]Statement, 0)
 	for !p.isAtEnd() {
 		statements = append(
@@ -225,13 +385,35 @@ func (p *Parser) parse() []Statement {
 	return statements
 }
 
+func (p *Parser) parseExpression() (expr Expression) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(parseError); !ok {
+				panic(r)
+			}
+			expr = nil
+		}
+	}()
+	expr = p.expression()
+	if !p.isAtEnd() {
+		panic(p.error(p.peek(), "Expect end of expression."))
+	}
+	return expr
+}
+
 func (p *Parser) declaration() Statement {
 	defer func() {
 		if r := recover(); r != nil {
+			if _, ok := r.(parseError); !ok {
+				panic(r)
+			}
 			p.synchronize()
 		}
 	}()
 
+	if p.match(FUN) {
+		return p.function()
+	}
 	if p.match(VAR) {
 		return p.varDeclaration()
 	}
@@ -247,7 +429,7 @@ func (p *Parser) varDeclaration() Statement {
 		initializer = p.expression()
 	}
 
-	p.consume(SEMICOLON, "Expect ';' after declaration")
+	p.consume(SEMICOLON, "Expect ';' after variable declaration.")
 
 	return &VarStatement{
 		Name:        name,
@@ -255,6 +437,41 @@ func (p *Parser) varDeclaration() Statement {
 	}
 }
 
+func (p *Parser) function() Statement {
+	keyword := p.previous()
+	name := p.consume(IDENTIFIER, "Expect function name.")
+	p.consume(LEFT_PAREN, "Expect '(' after function name.")
+
+	params := make([]Token, 0)
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(params) >= 255 {
+				p.error(p.peek(), "Can't have more than 255 parameters.")
+			}
+			params = append(params, p.consume(IDENTIFIER, "Expect parameter name."))
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
+
+	leftBrace := p.consume(LEFT_BRACE, "Expect '{' before function body.")
+	p.functions++
+	defer func() { p.functions-- }()
+	statements := p.block()
+	return &FunctionStatement{
+		Keyword: keyword,
+		Name:    name,
+		Params:  params,
+		Body: &BlockStatement{
+			LeftBrace:  leftBrace,
+			Statements: statements,
+			RightBrace: p.previous(),
+		},
+	}
+}
+
 func (p *Parser) block() []Statement {
 	statements := make([]Statement, 0)
 
@@ -265,7 +482,7 @@ func (p *Parser) block() []Statement {
 		)
 	}
 
-	p.consume(RIGHT_BRACE, "Expect '}' after block")
+	p.consume(RIGHT_BRACE, "Expect '}' after block.")
 	return statements
 }
 
@@ -276,15 +493,23 @@ func (p *Parser) statement() Statement {
 	if p.match(PRINT) {
 		return p.printStatement()
 	}
+	if p.match(RETURN) {
+		return p.returnStatement()
+	}
 	if p.match(LEFT_BRACE) {
+		leftBrace := p.previous()
+		statements := p.block()
 		return &BlockStatement{
-			Statements: p.block(),
+			LeftBrace:  leftBrace,
+			Statements: statements,
+			RightBrace: p.previous(),
 		}
 	}
 	return p.expressionStatement()
 }
 
 func (p *Parser) ifStatement() Statement {
+	keyword := p.previous()
 	p.consume(LEFT_PAREN, "Expect '(' after 'if'.")
 	condition := p.expression()
 	p.consume(RIGHT_PAREN, "Expect ')' after if condition.")
@@ -296,6 +521,7 @@ func (p *Parser) ifStatement() Statement {
 	}
 
 	return &IfStatement{
+		Keyword:    keyword,
 		Condition:  condition,
 		ThenBranch: thenBranch,
 		ElseBranch: elseBranch,
@@ -303,16 +529,34 @@ func (p *Parser) ifStatement() Statement {
 }
 
 func (p *Parser) printStatement() Statement {
+	keyword := p.previous()
 	value := p.expression()
 	p.consume(SEMICOLON, "Expect ';' after value.")
 	return &PrintStatement{
+		Keyword:    keyword,
 		Expression: value,
 	}
 }
 
+func (p *Parser) returnStatement() Statement {
+	keyword := p.previous()
+	if p.functions == 0 {
+		p.error(keyword, "Can't return from top-level code.")
+	}
+	var value Expression
+	if !p.check(SEMICOLON) {
+		value = p.expression()
+	}
+	p.consume(SEMICOLON, "Expect ';' after return value.")
+	return &ReturnStatement{
+		Keyword: keyword,
+		Value:   value,
+	}
+}
+
 func (p *Parser) expressionStatement() Statement {
 	value := p.expression()
-	p.consume(SEMICOLON, "Expect ';' after value.")
+	p.consume(SEMICOLON, "Expect ';' after expression.")
 	return &ExpressionStatement{
 		Expression: value,
 	}
@@ -327,14 +571,7 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().Type {
-		case CLASS:
-		case FUN:
-		case VAR:
-		case FOR:
-		case IF:
-		case WHILE:
-		case PRINT:
-		case RETURN:
+		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN:
 			return
 		}
 
@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format read
+// by `go tool pprof`. Each sample is a call stack of Lox functions with the
+// number of statements run and the time spent in them. filename names the
+// profiled program.
+func (p *Profiler) WritePprof(w io.Writer, filename string) error {
+	table := newStringTable()
+	functionIDs := make(map[string]uint64)
+	locationIDs := make(map[Frame]uint64)
+
+	var profile, functions, locations protobuf
+
+	// Samples are written in a stable order so that profiles of the same
+	// run compare equal.
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	samples := make([]protobuf, 0, len(keys))
+	for _, key := range keys {
+		sample := p.samples[key]
+		ids := make([]uint64, 0, len(sample.stack))
+		for _, frame := range sample.stack {
+			// pprof drops anything in angle brackets from function names,
+			// as it would C++ template arguments.
+			frame.Name = strings.Trim(frame.Name, "<>")
+			functionID, ok := functionIDs[frame.Name]
+			if !ok {
+				functionID = uint64(len(functionIDs) + 1)
+				functionIDs[frame.Name] = functionID
+				functions.message(5, func(function *protobuf) {
+					function.uint64(1, functionID)
+					function.int64(2, table.index(frame.Name))
+					function.int64(3, table.index(frame.Name))
+					function.int64(4, table.index(filename))
+				})
+			}
+
+			locationID, ok := locationIDs[frame]
+			if !ok {
+				locationID = uint64(len(locationIDs) + 1)
+				locationIDs[frame] = locationID
+				locations.message(4, func(location *protobuf) {
+					location.uint64(1, locationID)
+					location.message(4, func(line *protobuf) {
+						line.uint64(1, functionID)
+						line.int64(2, int64(frame.Line))
+					})
+				})
+			}
+			ids = append(ids, locationID)
+		}
+
+		var encoded protobuf
+		encoded.message(2, func(s *protobuf) {
+			s.packed(1, ids)
+			s.packed(2, []uint64{uint64(sample.count), uint64(sample.time.Nanoseconds())})
+		})
+		samples = append(samples, encoded)
+	}
+
+	valueType := func(field int, kind string, unit string) {
+		profile.message(field, func(v *protobuf) {
+			v.int64(1, table.index(kind))
+			v.int64(2, table.index(unit))
+		})
+	}
+	valueType(1, "statements", "count")
+	valueType(1, "time", "nanoseconds")
+	for _, sample := range samples {
+		profile.data = append(profile.data, sample.data...)
+	}
+	profile.data = append(profile.data, locations.data...)
+	profile.data = append(profile.data, functions.data...)
+	profile.int64(9, time.Now().UnixNano())
+	profile.int64(10, p.elapsed.Nanoseconds())
+	valueType(11, "time", "nanoseconds")
+	profile.int64(12, 1)
+	for _, s := range table.strings {
+		profile.string(6, s)
+	}
+
+	zipper := gzip.NewWriter(w)
+	if _, err := zipper.Write(profile.data); err != nil {
+		return err
+	}
+	return zipper.Close()
+}
+
+// stringTable interns the strings a pprof profile refers to by index. The
+// first string is always empty.
+type stringTable struct {
+	strings []string
+	indexes map[string]int64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{strings: []string{""}, indexes: map[string]int64{"": 0}}
+}
+
+func (t *stringTable) index(s string) int64 {
+	index, ok := t.indexes[s]
+	if !ok {
+		index = int64(len(t.strings))
+		t.strings = append(t.strings, s)
+		t.indexes[s] = index
+	}
+	return index
+}
+
+// protobuf encodes the few protocol buffer field types pprof needs.
+type protobuf struct {
+	data []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protobuf) tag(field int, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (b *protobuf) uint64(field int, x uint64) {
+	b.tag(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protobuf) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *protobuf) bytes(field int, data []byte) {
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protobuf) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *protobuf) packed(field int, values []uint64) {
+	var inner protobuf
+	for _, value := range values {
+		inner.varint(value)
+	}
+	b.bytes(field, inner.data)
+}
+
+func (b *protobuf) message(field int, build func(*protobuf)) {
+	var inner protobuf
+	build(&inner)
+	b.bytes(field, inner.data)
+}
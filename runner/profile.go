@@ -0,0 +1,217 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profiler records how often each line and function runs and how long it
+// takes. Set one on a LoxRunner before running a program, then write a
+// report with WriteText or WritePprof.
+//
+// Self time is spent in a line or function itself; total time also counts
+// the statements and calls nested inside it.
+type Profiler struct {
+	lines     map[int]*ProfileEntry
+	functions map[functionKey]*ProfileEntry
+	samples   map[string]*profileSample
+	// statements and calls are the activations in progress, innermost last.
+	statements []*activation
+	calls      []*activation
+	elapsed    time.Duration
+}
+
+// ProfileEntry is the profile of one line or function. Line is the line a
+// function was declared on, or zero for natives.
+type ProfileEntry struct {
+	Name  string
+	Line  int
+	Count int
+	Self  time.Duration
+	Total time.Duration
+
+	// active counts the activations in progress, so that recursion adds to
+	// Total only once.
+	active int
+}
+
+type functionKey struct {
+	name string
+	line int
+}
+
+type activation struct {
+	entry    *ProfileEntry
+	start    time.Time
+	children time.Duration
+}
+
+// profileSample is the time spent in statements with one particular call
+// stack, for pprof.
+type profileSample struct {
+	stack []Frame
+	count int64
+	time  time.Duration
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		lines:     make(map[int]*ProfileEntry),
+		functions: make(map[functionKey]*ProfileEntry),
+		samples:   make(map[string]*profileSample),
+	}
+}
+
+func (p *Profiler) enterStatement(line int) {
+	entry, ok := p.lines[line]
+	if !ok {
+		entry = &ProfileEntry{Name: fmt.Sprintf("line %v", line), Line: line}
+		p.lines[line] = entry
+	}
+	p.statements = p.enter(p.statements, entry)
+}
+
+func (p *Profiler) leaveStatement(interpreter *Interpreter) {
+	var current *activation
+	var elapsed, self time.Duration
+	p.statements, current, elapsed, self = p.leave(p.statements)
+	if len(p.statements) == 0 {
+		p.elapsed += elapsed
+	}
+
+	// The stack is the statement's own line in the current function, then
+	// the line each caller is running.
+	frames := interpreter.frames
+	stack := make([]Frame, 0, len(frames))
+	for index := len(frames) - 1; index >= 0; index-- {
+		frame := Frame{Name: frames[index].Name, Line: frames[index].Line}
+		if index == len(frames)-1 {
+			frame.Line = current.entry.Line
+		}
+		stack = append(stack, frame)
+	}
+
+	var key strings.Builder
+	for _, frame := range stack {
+		fmt.Fprintf(&key, "%v:%v;", frame.Name, frame.Line)
+	}
+	sample, ok := p.samples[key.String()]
+	if !ok {
+		sample = &profileSample{stack: stack}
+		p.samples[key.String()] = sample
+	}
+	sample.count++
+	sample.time += self
+}
+
+func (p *Profiler) enterCall(callee LoxCallable) {
+	key := functionKey{name: fmt.Sprint(callee)}
+	switch function := callee.(type) {
+	case *LoxFunction:
+		key = functionKey{function.Declaration.Name.Lexeme, function.Declaration.Name.Line}
+	case *NativeFunction:
+		key = functionKey{name: function.Name}
+	}
+
+	entry, ok := p.functions[key]
+	if !ok {
+		entry = &ProfileEntry{Name: key.name, Line: key.line}
+		p.functions[key] = entry
+	}
+	p.calls = p.enter(p.calls, entry)
+}
+
+func (p *Profiler) leaveCall() {
+	p.calls, _, _, _ = p.leave(p.calls)
+}
+
+func (p *Profiler) enter(stack []*activation, entry *ProfileEntry) []*activation {
+	entry.Count++
+	entry.active++
+	return append(stack, &activation{entry: entry, start: time.Now()})
+}
+
+// leave pops the innermost activation, crediting its time to its entry and
+// to its parent's children.
+func (p *Profiler) leave(stack []*activation) ([]*activation, *activation, time.Duration, time.Duration) {
+	current := stack[len(stack)-1]
+	stack = stack[:len(stack)-1]
+
+	elapsed := time.Since(current.start)
+	self := elapsed - current.children
+	current.entry.Self += self
+	current.entry.active--
+	if current.entry.active == 0 {
+		current.entry.Total += elapsed
+	}
+	if len(stack) > 0 {
+		stack[len(stack)-1].children += elapsed
+	}
+	return stack, current, elapsed, self
+}
+
+// Lines returns the profile of every line that ran, most self time first.
+func (p *Profiler) Lines() []ProfileEntry {
+	entries := make([]ProfileEntry, 0, len(p.lines))
+	for _, entry := range p.lines {
+		entries = append(entries, *entry)
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Functions returns the profile of every function called, most self time
+// first.
+func (p *Profiler) Functions() []ProfileEntry {
+	entries := make([]ProfileEntry, 0, len(p.functions))
+	for _, entry := range p.functions {
+		entries = append(entries, *entry)
+	}
+	sortEntries(entries)
+	return entries
+}
+
+func sortEntries(entries []ProfileEntry) {
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Self != entries[b].Self {
+			return entries[a].Self > entries[b].Self
+		}
+		return entries[a].Line < entries[b].Line
+	})
+}
+
+// WriteText writes a readable report. When source is the profiled program,
+// each line is shown next to its profile.
+func (p *Profiler) WriteText(w io.Writer, source string) error {
+	sourceLines := strings.Split(source, "\n")
+
+	fmt.Fprintf(w, "Total time: %v\n", p.elapsed)
+
+	functions := p.Functions()
+	if len(functions) > 0 {
+		fmt.Fprintf(w, "\n%-24v %10v %12v %12v\n", "FUNCTION", "CALLS", "SELF", "TOTAL")
+		for _, entry := range functions {
+			name := entry.Name
+			if entry.Line > 0 {
+				name = fmt.Sprintf("%v (line %v)", entry.Name, entry.Line)
+			}
+			fmt.Fprintf(w, "%-24v %10v %12v %12v\n", name, entry.Count, entry.Self, entry.Total)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%-8v %10v %12v %12v  %v\n", "LINE", "COUNT", "SELF", "TOTAL", "SOURCE")
+	for _, entry := range p.Lines() {
+		text := ""
+		if entry.Line >= 1 && entry.Line <= len(sourceLines) {
+			text = strings.TrimSpace(sourceLines[entry.Line-1])
+		}
+		_, err := fmt.Fprintf(w, "%-8v %10v %12v %12v  %v\n", entry.Line, entry.Count, entry.Self, entry.Total, text)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+const profiledSource = `fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 1) + fib(n - 2);
+}
+print fib(5);
+`
+
+func TestProfiler(t *testing.T) {
+	profiler := NewProfiler()
+	r := LoxRunner{Output: io.Discard, Profiler: profiler}
+	if err := r.Run(context.Background(), profiledSource); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[int]int)
+	for _, entry := range profiler.Lines() {
+		counts[entry.Line] = entry.Count
+	}
+	// fib(5) makes 15 calls, 7 of which recurse. Line 2 counts both the if
+	// and, for the other 8, its return.
+	want := map[int]int{1: 1, 2: 23, 3: 7, 5: 1}
+	for line, count := range want {
+		if counts[line] != count {
+			t.Errorf("line %v ran %v times, want %v", line, counts[line], count)
+		}
+	}
+
+	functions := profiler.Functions()
+	if len(functions) != 1 || functions[0].Name != "fib" || functions[0].Count != 15 {
+		t.Errorf("functions = %+v, want fib called 15 times", functions)
+	}
+
+	var text bytes.Buffer
+	if err := profiler.WriteText(&text, profiledSource); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "return fib(n - 1) + fib(n - 2);") {
+		t.Errorf("text report doesn't show the source:\n%v", text.String())
+	}
+
+	var pprof bytes.Buffer
+	if err := profiler.WritePprof(&pprof, "fib.lox"); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(&pprof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("fib.lox")) {
+		t.Error("pprof profile doesn't name the file")
+	}
+}
@@ -0,0 +1,188 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rdtharri/go-lox/lineedit"
+)
+
+// replCommands documents the commands the REPL understands, for :help.
+var replCommands = [][2]string{
+	{":env", "list the variables defined in the session"},
+	{":type <expr>", "evaluate an expression and print its type"},
+	{":ast <code>", "print the syntax tree of an expression or statements"},
+	{":tokens <code>", "print the tokens in some code"},
+	{":load <file>", "run a file in the session"},
+	{":reset", "start a new session"},
+	{":help", "show this help"},
+	{":quit", "leave the REPL"},
+}
+
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), ":")
+}
+
+// command runs a REPL command and reports whether the REPL should carry on.
+func (r *LoxRunner) command(line string) bool {
+	name, argument, _ := strings.Cut(strings.TrimSpace(line), " ")
+	argument = strings.TrimSpace(argument)
+	output := r.output()
+
+	switch name {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		for _, command := range replCommands {
+			fmt.Fprintf(output, "%-16v%v\n", command[0], command[1])
+		}
+	case ":env":
+		r.printEnvironment(output)
+	case ":type":
+		expr := r.ParseExpression(argument)
+		if r.HadError {
+			break
+		}
+		value, err := r.Interpreter.evaluateTop(context.Background(), expr)
+		if err != nil {
+			r.runtimeError(err)
+			break
+		}
+		fmt.Fprintln(output, TypeName(value))
+	case ":ast":
+		printer := AstPrinter{}
+		trimmed := strings.TrimSpace(argument)
+		if strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, "}") {
+			stmts := r.Parse(argument)
+			if !r.HadError {
+				fmt.Fprintln(output, printer.Print(stmts))
+			}
+		} else if expr := r.ParseExpression(argument); !r.HadError {
+			fmt.Fprintln(output, printer.PrintExpression(expr))
+		}
+	case ":tokens":
+		for _, token := range r.Scan(argument) {
+			fmt.Fprintf(output, "%v:%v\t%v\n", token.Line, token.Column, token.ToString())
+		}
+	case ":load":
+		data, err := os.ReadFile(argument)
+		if err != nil {
+			fmt.Fprintln(r.errorOutput(), err)
+			break
+		}
+		r.runIn(context.Background(), r.Interpreter, string(data))
+	case ":reset":
+		r.Interpreter.closeFiles()
+		r.Interpreter = r.newInterpreter()
+		fmt.Fprintln(output, "Session reset.")
+	default:
+		fmt.Fprintf(r.errorOutput(), "Unknown command '%v'. Type :help for a list.\n", name)
+	}
+	return true
+}
+
+// printEnvironment lists the bindings in each environment of the session,
+// innermost first. Natives and native namespaces are left out.
+func (r *LoxRunner) printEnvironment(output io.Writer) {
+	for env := r.Interpreter.Environment; env != nil; env = env.Enclosing {
+		names := make([]string, 0, len(env.Values))
+		for name, value := range env.Values {
+			if _, ok := value.(*NativeFunction); ok {
+				continue
+			}
+			if _, ok := value.(*LoxMap); ok && env.Enclosing == nil && Natives.Defines(name) {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if env.Enclosing == nil {
+			fmt.Fprintln(output, "globals:")
+		} else {
+			fmt.Fprintln(output, "block:")
+		}
+		for _, name := range names {
+			fmt.Fprintf(output, "  %v = %v\n", name, Stringify(env.Values[name]))
+		}
+	}
+}
+
+func (r *LoxRunner) output() io.Writer {
+	if r.Output == nil {
+		return os.Stdout
+	}
+	return r.Output
+}
+
+// historyFile is where the REPL keeps history, relative to the home
+// directory.
+const historyFile = ".go_lox_history"
+
+// newLineReader returns a function reading one REPL line at a time. A
+// terminal gets a line editor with persistent history and completion;
+// anything else is read plainly, through a reader the program's input and
+// readLine share so that neither buffers lines meant for the other.
+func (r *LoxRunner) newLineReader() func(prompt string) (string, error) {
+	if !lineedit.IsTerminal(os.Stdin) {
+		reader := bufio.NewReader(os.Stdin)
+		if r.Input == nil {
+			r.Input = reader
+		}
+		return func(prompt string) (string, error) {
+			fmt.Print(prompt)
+			text, err := reader.ReadString('\n')
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(text, "\r\n"), nil
+		}
+	}
+
+	editor := lineedit.New(os.Stdin, os.Stdout)
+	editor.Complete = r.complete
+	if home, err := os.UserHomeDir(); err == nil {
+		editor.HistoryFile = filepath.Join(home, historyFile)
+		editor.LoadHistory()
+	}
+	return editor.ReadLine
+}
+
+// complete offers the REPL commands at the start of a line, and otherwise
+// keywords and the names visible in the session.
+func (r *LoxRunner) complete(line string, word string) []string {
+	var names []string
+	if isCommand(line) && !strings.Contains(strings.TrimSpace(line), " ") {
+		for _, command := range replCommands {
+			name, _, _ := strings.Cut(command[0], " ")
+			names = append(names, name)
+		}
+	} else {
+		for keyword := range KeyMap {
+			names = append(names, keyword)
+		}
+		seen := map[string]bool{}
+		for env := r.Interpreter.Environment; env != nil; env = env.Enclosing {
+			for name := range env.Values {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	var candidates []string
+	for _, name := range names {
+		if word != "" && strings.HasPrefix(name, word) {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplCommands(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.lox")
+	if err := os.WriteFile(script, []byte("var loaded = 2;\nprint \"loaded\";\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		commands []string
+		output   string
+		errors   string
+	}{
+		{
+			name:     "env",
+			commands: []string{":env"},
+			output:   "globals:\n  a = 1\n  args = []\n",
+		},
+		{
+			name:     "type",
+			commands: []string{":type a", ":type [a]", ":type nope"},
+			output:   "number\nlist\n",
+			errors:   "Undefined variable 'nope'.\n[line 1]\n",
+		},
+		{
+			name:     "ast",
+			commands: []string{":ast a + b * c", ":ast if (a) print 1;"},
+			output:   "(+ a (* b c))\n(if a\n  (print 1))\n",
+		},
+		{
+			name:     "tokens",
+			commands: []string{":tokens 1 + x"},
+			output:   "1:1\tNUMBER 1\n1:3\tPLUS +\n1:5\tIDENTIFIER x\n1:6\tEOF \n",
+		},
+		{
+			name:     "load",
+			commands: []string{":load " + script, ":type loaded", ":load missing.lox"},
+			output:   "loaded\nnumber\n",
+			errors:   "open missing.lox: no such file or directory\n",
+		},
+		{
+			name:     "reset",
+			commands: []string{":reset", ":env"},
+			output:   "Session reset.\nglobals:\n  args = []\n",
+		},
+		{
+			name:     "unknown",
+			commands: []string{":bogus"},
+			errors:   "Unknown command ':bogus'. Type :help for a list.\n",
+		},
+	}
+	for _, test := range tests {
+		var output, errorOutput bytes.Buffer
+		r := LoxRunner{Output: &output, ErrorOutput: &errorOutput}
+		r.Interpreter = r.newInterpreter()
+		r.runIn(context.Background(), r.Interpreter, "var a = 1;")
+
+		for _, command := range test.commands {
+			if !r.command(command) {
+				t.Errorf("%v: %v ended the session", test.name, command)
+			}
+		}
+		if output.String() != test.output {
+			t.Errorf("%v: output = %q, want %q", test.name, output.String(), test.output)
+		}
+		if errorOutput.String() != test.errors {
+			t.Errorf("%v: errors = %q, want %q", test.name, errorOutput.String(), test.errors)
+		}
+	}
+}
+
+func TestReplQuitAndHelp(t *testing.T) {
+	var output bytes.Buffer
+	r := LoxRunner{Output: &output}
+	r.Interpreter = r.newInterpreter()
+	if !r.command(":help") {
+		t.Error(":help ended the session")
+	}
+	for _, command := range replCommands {
+		if !strings.Contains(output.String(), command[1]) {
+			t.Errorf(":help doesn't describe %v", command[0])
+		}
+	}
+	if r.command(":quit") || r.command(":q") {
+		t.Error(":quit didn't end the session")
+	}
+}
+
+func TestReplInput(t *testing.T) {
+	// Piped into the REPL, the lines a program reads come from the same
+	// stream as the lines it runs.
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin.WriteString("var name = readLine();\nworld\nprint \"hello \" + name;\n")
+	stdin.Seek(0, 0)
+	prompts, err := os.Create(filepath.Join(t.TempDir(), "prompts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, savedOut := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, prompts
+	t.Cleanup(func() { os.Stdin, os.Stdout = saved, savedOut })
+
+	var output bytes.Buffer
+	r := LoxRunner{Output: &output}
+	r.RunPrompt()
+	if output.String() != "hello world\n" {
+		t.Errorf("output = %q, want %q", output.String(), "hello world\n")
+	}
+}
@@ -1,15 +1,45 @@
 package runner
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+
+	"github.com/rdtharri/go-lox/lineedit"
 )
 
 type LoxRunner struct {
-	HadError bool
-	Scanner  *Scanner
-	Parser   *Parser
+	HadError        bool
+	HadRuntimeError bool
+	// Errors collects every scan and parse error reported, in order.
+	Errors []SyntaxError
+	// Output receives everything programs print and ErrorOutput receives
+	// reported errors. Both default to os.Stdout.
+	Output       io.Writer
+	ErrorOutput  io.Writer
+	Limits       Limits
+	Capabilities Capabilities
+	Hooks        Hooks
+	Profiler     *Profiler
+	Coverage     *Coverage
+	Tracer       *Tracer
+	Scanner      *Scanner
+	Parser       *Parser
+	Interpreter  *Interpreter
+	// Strict makes dividing by zero a runtime error rather than giving an
+	// infinity or NaN.
+	Strict bool
+	// Args is defined for programs as the list args.
+	Args []string
+	// Input is what the input and readLine natives read. It defaults to
+	// os.Stdin.
+	Input io.Reader
+	// Exited is set when a program calls exit, with the status it gave in
+	// ExitCode.
+	Exited   bool
+	ExitCode int
 }
 
 func (r *LoxRunner) RunFile(path string) {
@@ -17,48 +47,154 @@ func (r *LoxRunner) RunFile(path string) {
 	if err != nil {
 		panic(err)
 	}
-	r.run(string(data))
+	r.run(context.Background(), string(data))
 }
 
+// RunPrompt reads and runs lines from stdin in a single session, so that
+// definitions carry over from one line to the next. Lines starting with ':'
+// are REPL commands; see command. On a terminal, lines can be edited and
+// recalled from history; see newLineReader.
 func (r *LoxRunner) RunPrompt() {
 
-	reader := bufio.NewReader(os.Stdin)
+	readLine := r.newLineReader()
+	r.Interpreter = r.newInterpreter()
 	for {
-		fmt.Print("> ")
-		text, err := reader.ReadString('\n')
+		text, err := readLine("> ")
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
 		if err != nil {
 			fmt.Println("")
 			break
 		}
-		r.run(text)
+		if isCommand(text) {
+			if !r.command(text) {
+				break
+			}
+		} else {
+			r.runIn(context.Background(), r.Interpreter, text)
+		}
+		if r.Exited {
+			break
+		}
+		r.HadError = false
+		r.HadRuntimeError = false
 	}
+	r.Interpreter.closeFiles()
+}
 
+// Run executes program under ctx and r.Limits. The returned error is the
+// RuntimeError that stopped the program, if any; scan and parse errors are
+// reported and flagged through HadError. Files the program leaves open are
+// closed when it ends.
+func (r *LoxRunner) Run(ctx context.Context, program string) error {
+	return r.run(ctx, program)
 }
 
-func (r *LoxRunner) run(program string) {
+// Scan returns the tokens in program. Errors are reported as usual and
+// flagged through HadError.
+func (r *LoxRunner) Scan(program string) []Token {
+	r.Errors = nil
 	r.Scanner = NewScanner(program, r)
-	r.Scanner.ScanTokens()
+	return r.Scanner.ScanTokens()
+}
+
+// Parse scans and parses program without running it. Errors are reported as
+// usual and flagged through HadError.
+func (r *LoxRunner) Parse(program string) []Statement {
+	r.Parser = NewParser(r.Scan(program), r)
+	return r.Parser.parse()
+}
+
+// ParseExpression parses source as a single expression, returning nil if it
+// isn't one.
+func (r *LoxRunner) ParseExpression(source string) Expression {
+	r.Parser = NewParser(r.Scan(source), r)
+	return r.Parser.parseExpression()
+}
+
+func (r *LoxRunner) run(ctx context.Context, program string) error {
+	r.Interpreter = r.newInterpreter()
+	defer r.Interpreter.closeFiles()
+	return r.runIn(ctx, r.Interpreter, program)
+}
+
+// runIn runs program in an existing interpreter, keeping whatever it has
+// already defined.
+func (r *LoxRunner) runIn(ctx context.Context, interpreter *Interpreter, program string) error {
+	stmts := r.Parse(program)
+	if r.HadError {
+		return nil
+	}
+	if r.Coverage != nil {
+		r.Coverage.add(stmts)
+	}
 
-	r.Parser = NewParser(r.Scanner.Tokens, r)
-	stmts := r.Parser.parse()
+	err := interpreter.interpret(ctx, stmts)
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		r.Exited, r.ExitCode = true, exit.Code
+	} else if err != nil {
+		r.runtimeError(err)
+	}
+	return err
+}
+
+func (r *LoxRunner) newInterpreter() *Interpreter {
+	interpreter := NewInterpreter(r.Capabilities)
+	interpreter.Limits = r.Limits
+	interpreter.Strict = r.Strict
+	interpreter.Hooks = r.Hooks
+	interpreter.Profiler = r.Profiler
+	interpreter.Coverage = r.Coverage
+	interpreter.Tracer = r.Tracer
+	if r.Output != nil {
+		interpreter.Output = r.Output
+	}
+	if r.Input != nil {
+		interpreter.Input = r.Input
+	}
 
-	interpreter := NewInterpreter()
-	interpreter.interpret(stmts)
+	args := make([]interface{}, len(r.Args))
+	for index, arg := range r.Args {
+		args[index] = arg
+	}
+	interpreter.Environment.Define("args", NewList(args))
+	return &interpreter
 }
 
-func (r *LoxRunner) error(line int, message string) {
-	r.report(line, "", message)
+func (r *LoxRunner) error(line int, column int, message string) {
+	r.report(SyntaxError{Line: line, Column: column, Length: 1, Message: message})
 }
 
-func (r *LoxRunner) report(line int, where string, message string) {
-	fmt.Printf("[line %v] Error %v: %v\n", line, where, message)
+func (r *LoxRunner) report(err SyntaxError) {
+	fmt.Fprintln(r.errorOutput(), err.Error())
+	r.Errors = append(r.Errors, err)
 	r.HadError = true
 }
 
+func (r *LoxRunner) runtimeError(err error) {
+	fmt.Fprintln(r.errorOutput(), err)
+	r.HadRuntimeError = true
+}
+
+func (r *LoxRunner) errorOutput() io.Writer {
+	if r.ErrorOutput == nil {
+		return os.Stdout
+	}
+	return r.ErrorOutput
+}
+
 func (r *LoxRunner) tokenError(token Token, message string) {
+	err := SyntaxError{
+		Line:    token.Line,
+		Column:  token.Column,
+		Length:  len([]rune(token.Lexeme)),
+		Where:   " at '" + token.Lexeme + "'",
+		Message: message,
+	}
 	if token.Type == EOF {
-		r.report(token.Line, " at end", message)
-	} else {
-		r.report(token.Line, " at '"+token.Lexeme+"'", message)
+		err.Where = " at end"
 	}
+	r.report(err)
 }
@@ -1,23 +1,32 @@
 package runner
 
 import (
-	"strconv"
+	"strings"
 )
 
 type Scanner struct {
-	Source string
-	Tokens []Token
-	Runner *LoxRunner
-
-	start   int
-	current int
-	line    int
+	Source   string
+	Tokens   []Token
+	Comments []Token
+	Runner   *LoxRunner
+
+	// source is Source as runes, which start and current index into.
+	source      []rune
+	start       int
+	current     int
+	line        int
+	lineStart   int
+	startColumn int
+	// interpolations counts the braces open in each interpolated
+	// expression being scanned, innermost last.
+	interpolations []int
 }
 
 func NewScanner(source string, runner *LoxRunner) *Scanner {
 	scanner := new(Scanner)
 	scanner.line = 1
 	scanner.Source = source
+	scanner.source = []rune(source)
 	scanner.Runner = runner
 	return scanner
 }
@@ -25,15 +34,20 @@ func NewScanner(source string, runner *LoxRunner) *Scanner {
 func (s *Scanner) ScanTokens() []Token {
 	for !s.isAtEnd() {
 		s.start = s.current
+		s.startColumn = s.current - s.lineStart + 1
 		s.scanToken()
 	}
+	if len(s.interpolations) > 0 {
+		s.Runner.error(s.line, s.current-s.lineStart+1, "Unterminated string.")
+	}
 	s.start = s.current
+	s.startColumn = s.current - s.lineStart + 1
 	s.addNullToken(EOF)
 	return s.Tokens
 }
 
 func (s *Scanner) isAtEnd() bool {
-	return s.current >= len(s.Source)
+	return s.current >= len(s.source)
 }
 
 func (s *Scanner) scanToken() {
@@ -44,9 +58,29 @@ func (s *Scanner) scanToken() {
 	case ')':
 		s.addNullToken(RIGHT_PAREN)
 	case '{':
+		if depth := len(s.interpolations); depth > 0 {
+			s.interpolations[depth-1]++
+		}
 		s.addNullToken(LEFT_BRACE)
 	case '}':
+		depth := len(s.interpolations)
+		if depth > 0 && s.interpolations[depth-1] == 0 {
+			// The brace ends an interpolated expression, so the string
+			// it's in carries on.
+			s.interpolations = s.interpolations[:depth-1]
+			s.string()
+			return
+		}
+		if depth > 0 {
+			s.interpolations[depth-1]--
+		}
 		s.addNullToken(RIGHT_BRACE)
+	case '[':
+		s.addNullToken(LEFT_BRACKET)
+	case ']':
+		s.addNullToken(RIGHT_BRACKET)
+	case ':':
+		s.addNullToken(COLON)
 	case ',':
 		s.addNullToken(COMMA)
 	case '.':
@@ -57,8 +91,14 @@ func (s *Scanner) scanToken() {
 		s.addNullToken(PLUS)
 	case ';':
 		s.addNullToken(SEMICOLON)
+	case '%':
+		s.addNullToken(PERCENT)
 	case '*':
-		s.addNullToken(STAR)
+		if s.match('*') {
+			s.addNullToken(STAR_STAR)
+		} else {
+			s.addNullToken(STAR)
+		}
 	case '!':
 		if s.match('=') {
 			s.addNullToken(BANG_EQUAL)
@@ -88,6 +128,7 @@ func (s *Scanner) scanToken() {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.addComment()
 		} else {
 			s.addNullToken(SLASH)
 		}
@@ -95,7 +136,7 @@ func (s *Scanner) scanToken() {
 	case '\r':
 	case '\t':
 	case '\n':
-		s.line++
+		s.newline()
 	case '"':
 		s.string()
 	default:
@@ -104,13 +145,13 @@ func (s *Scanner) scanToken() {
 		} else if s.isAlpha(char) {
 			s.identifier()
 		} else {
-			s.Runner.error(s.line, "Unexpected character.")
+			s.Runner.error(s.line, s.startColumn, "Unexpected character.")
 		}
 	}
 }
 
 func (s *Scanner) advance() rune {
-	retVal := []rune(s.Source)[s.current]
+	retVal := s.source[s.current]
 	s.current++
 	return retVal
 }
@@ -120,7 +161,7 @@ func (s *Scanner) match(check rune) bool {
 		return false
 	}
 
-	if []rune(s.Source)[s.current] != check {
+	if s.source[s.current] != check {
 		return false
 	}
 
@@ -132,30 +173,49 @@ func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return '\n'
 	}
-	return []rune(s.Source)[s.current]
+	return s.source[s.current]
 }
 
 func (s *Scanner) peekNext() rune {
-	if s.current+1 >= len(s.Source) {
+	if s.current+1 >= len(s.source) {
 		return '\n'
 	}
-	return []rune(s.Source)[s.current+1]
+	return s.source[s.current+1]
 }
 
+// newline is called just after a '\n' has been consumed.
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
+}
+
+// string scans a string literal, or the rest of one after an interpolated
+// expression, up to its closing quote or the next '${'.
 func (s *Scanner) string() {
 
 	for s.peek() != '"' && !s.isAtEnd() {
 		if s.peek() == '\n' {
-			s.line++
+			s.advance()
+			s.newline()
+			continue
+		}
+		if s.peek() == '$' && s.peekNext() == '{' {
+			s.advance()
+			s.advance()
+			s.addValueToken(INTERPOLATION, string(s.source[(s.start+1):(s.current-2)]))
+			s.interpolations = append(s.interpolations, 0)
+			return
 		}
 		s.advance()
 	}
 
 	if s.isAtEnd() {
-		s.Runner.error(s.line, "Unterminated string.")
+		s.Runner.error(s.line, s.startColumn, "Unterminated string.")
+		s.interpolations = nil
+		return
 	}
 	s.advance()
-	s.addValueToken(STRING, string(s.Source[(s.start+1):(s.current-1)]))
+	s.addValueToken(STRING, string(s.source[(s.start+1):(s.current-1)]))
 
 }
 
@@ -172,38 +232,60 @@ func (s *Scanner) isAlphaNumeric(char rune) bool {
 	return s.isDigit(char) || s.isAlpha(char)
 }
 
+// number scans a number literal: decimal with an optional fraction and
+// exponent, or hexadecimal (0x1F) or binary (0b101). Digits may be
+// separated with underscores. A base prefix or an exponent marker commits to
+// the literal, so 0x or 1e without digits is an invalid literal rather than a
+// number followed by a name.
 func (s *Scanner) number() {
-	for s.isDigit(s.peek()) {
+	if s.source[s.start] == '0' && strings.ContainsRune("xXbB", s.peek()) {
 		s.advance()
+		for s.isAlphaNumeric(s.peek()) {
+			s.advance()
+		}
+		s.addValueToken(NUMBER, s.lexeme())
+		return
 	}
 
+	s.digits()
 	if s.peek() == '.' && s.isDigit(s.peekNext()) {
 		s.advance()
+		s.digits()
+	}
 
-		for s.isDigit(s.peek()) {
+	if s.peek() == 'e' || s.peek() == 'E' {
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		s.digits()
 	}
 
-	s.addValueToken(NUMBER, string(s.Source[(s.start):(s.current)]))
+	s.addValueToken(NUMBER, s.lexeme())
 
 }
 
+func (s *Scanner) digits() {
+	for s.isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
 func (s *Scanner) identifier() {
 	for s.isAlphaNumeric(s.peek()) {
 		s.advance()
 	}
 
-	lexeme := string(s.Source[(s.start):(s.current)])
+	lexeme := s.lexeme()
 	ttype, found := KeyMap[lexeme]
 	if !found {
 		ttype = IDENTIFIER
 	}
 
 	if ttype == TRUE || ttype == FALSE {
-		s.addValueToken(ttype,lexeme)
+		s.addValueToken(ttype, lexeme)
 	} else {
-	    s.addNullToken(ttype)
+		s.addNullToken(ttype)
 	}
 }
 
@@ -211,9 +293,10 @@ func (s *Scanner) addNullToken(ttype TokenType) {
 	s.appendToken(
 		Token{
 			Type:   ttype,
-			Lexeme: string(s.Source[(s.start):(s.current)]),
+			Lexeme: s.lexeme(),
 			Value:  nil,
 			Line:   s.line,
+			Column: s.startColumn,
 		},
 	)
 }
@@ -221,20 +304,19 @@ func (s *Scanner) addNullToken(ttype TokenType) {
 func (s *Scanner) addValueToken(ttype TokenType, value string) {
 	newToken := Token{
 		Type:   ttype,
-		Lexeme: string(s.Source[(s.start):(s.current)]),
+		Lexeme: s.lexeme(),
 		Line:   s.line,
+		Column: s.startColumn,
 	}
 
 	switch ttype {
 	case NUMBER:
-		numVal, err := strconv.ParseFloat(value, 64)
+		numVal, err := parseNumber(value)
 		if err != nil {
-			// Not sure how to hit this or what should happen
-			// TODO: add better panic-recover to scanner
-			panic(err)
+			s.Runner.error(s.line, s.startColumn, err.Error())
 		}
 		newToken.Value = numVal
-	case STRING:
+	case STRING, INTERPOLATION:
 		newToken.Value = value
 	case TRUE:
 		newToken.Value = true
@@ -245,6 +327,19 @@ func (s *Scanner) addValueToken(ttype TokenType, value string) {
 	s.appendToken(newToken)
 }
 
+func (s *Scanner) addComment() {
+	s.Comments = append(s.Comments, Token{
+		Type:   COMMENT,
+		Lexeme: s.lexeme(),
+		Line:   s.line,
+		Column: s.startColumn,
+	})
+}
+
+func (s *Scanner) lexeme() string {
+	return string(s.source[s.start:s.current])
+}
+
 func (s *Scanner) appendToken(token Token) {
 	s.Tokens = append(s.Tokens, token)
 }
@@ -6,6 +6,8 @@ type StatementVisitor interface {
 	VisitVarStatement(*VarStatement)
 	VisitBlockStatement(*BlockStatement)
 	VisitIfStatement(*IfStatement)
+	VisitFunctionStatement(*FunctionStatement)
+	VisitReturnStatement(*ReturnStatement)
 }
 
 type Statement interface {
@@ -21,6 +23,7 @@ func (es *ExpressionStatement) Accept(v StatementVisitor) {
 }
 
 type PrintStatement struct {
+	Keyword    Token
 	Expression Expression
 }
 
@@ -38,7 +41,9 @@ func (vs *VarStatement) Accept(v StatementVisitor) {
 }
 
 type BlockStatement struct {
+	LeftBrace  Token
 	Statements []Statement
+	RightBrace Token
 }
 
 func (bs *BlockStatement) Accept(v StatementVisitor) {
@@ -46,6 +51,7 @@ func (bs *BlockStatement) Accept(v StatementVisitor) {
 }
 
 type IfStatement struct {
+	Keyword    Token
 	Condition  Expression
 	ThenBranch Statement
 	ElseBranch Statement
@@ -54,3 +60,23 @@ type IfStatement struct {
 func (is *IfStatement) Accept(v StatementVisitor) {
 	v.VisitIfStatement(is)
 }
+
+type FunctionStatement struct {
+	Keyword Token
+	Name    Token
+	Params  []Token
+	Body    *BlockStatement
+}
+
+func (fs *FunctionStatement) Accept(v StatementVisitor) {
+	v.VisitFunctionStatement(fs)
+}
+
+type ReturnStatement struct {
+	Keyword Token
+	Value   Expression
+}
+
+func (rs *ReturnStatement) Accept(v StatementVisitor) {
+	v.VisitReturnStatement(rs)
+}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStringLength caps the strings natives build, in bytes, so that a
+// program can't exhaust memory with one call.
+const maxStringLength = 1 << 28
+
+// registerStringNative registers a native whose arguments are all strings.
+func registerStringNative(name string, params int, fn func(arguments []string) (interface{}, error)) {
+	Natives.Register(&NativeFunction{
+		Name:   name,
+		Params: params,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			texts := make([]string, len(arguments))
+			for index := range arguments {
+				text, err := argument[string](name, arguments, index)
+				if err != nil {
+					return nil, err
+				}
+				texts[index] = text
+			}
+			return fn(texts)
+		},
+	})
+}
+
+// characters splits text into a list of its code points.
+func characters(text string) *LoxList {
+	elements := make([]interface{}, 0, utf8.RuneCountInString(text))
+	for _, char := range text {
+		elements = append(elements, string(char))
+	}
+	return NewList(elements)
+}
+
+func init() {
+	registerStringNative("contains", 2, func(arguments []string) (interface{}, error) {
+		return strings.Contains(arguments[0], arguments[1]), nil
+	})
+
+	registerStringNative("startsWith", 2, func(arguments []string) (interface{}, error) {
+		return strings.HasPrefix(arguments[0], arguments[1]), nil
+	})
+
+	registerStringNative("endsWith", 2, func(arguments []string) (interface{}, error) {
+		return strings.HasSuffix(arguments[0], arguments[1]), nil
+	})
+
+	// indexOf counts in code points, like indexing, and returns -1 when the
+	// substring isn't found. It always searches from the start; to search
+	// further on, slice the string first and add the offset back.
+	registerStringNative("indexOf", 2, func(arguments []string) (interface{}, error) {
+		index := strings.Index(arguments[0], arguments[1])
+		if index < 0 {
+			return -1.0, nil
+		}
+		return float64(utf8.RuneCountInString(arguments[0][:index])), nil
+	})
+
+	registerStringNative("trim", 1, func(arguments []string) (interface{}, error) {
+		return strings.TrimSpace(arguments[0]), nil
+	})
+
+	registerStringNative("upper", 1, func(arguments []string) (interface{}, error) {
+		return strings.ToUpper(arguments[0]), nil
+	})
+
+	registerStringNative("lower", 1, func(arguments []string) (interface{}, error) {
+		return strings.ToLower(arguments[0]), nil
+	})
+
+	registerStringNative("replace", 3, func(arguments []string) (interface{}, error) {
+		text, old, replacement := arguments[0], arguments[1], arguments[2]
+		count := strings.Count(text, old)
+		if len(text)+count*(len(replacement)-len(old)) > maxStringLength {
+			return nil, &RuntimeError{Message: "String is too long."}
+		}
+		return strings.ReplaceAll(text, old, replacement), nil
+	})
+
+	// split with an empty separator splits into code points.
+	registerStringNative("split", 2, func(arguments []string) (interface{}, error) {
+		if arguments[1] == "" {
+			return characters(arguments[0]), nil
+		}
+		parts := strings.Split(arguments[0], arguments[1])
+		elements := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			elements = append(elements, part)
+		}
+		return NewList(elements), nil
+	})
+
+	registerStringNative("chars", 1, func(arguments []string) (interface{}, error) {
+		return characters(arguments[0]), nil
+	})
+
+	registerStringNative("ord", 1, func(arguments []string) (interface{}, error) {
+		char, size := utf8.DecodeRuneInString(arguments[0])
+		if size == 0 || size != len(arguments[0]) {
+			return nil, invalidArgument("ord", 0, "a single character")
+		}
+		return float64(char), nil
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "chr",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			code, err := integerArgument("chr", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+				return nil, invalidArgument("chr", 0, "a valid code point")
+			}
+			return string(rune(code)), nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "repeat",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			text, err := argument[string]("repeat", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			count, err := integerArgument("repeat", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if count < 0 {
+				return nil, invalidArgument("repeat", 1, "zero or more")
+			}
+			if count > maxStringLength || float64(len(text))*count > maxStringLength {
+				return nil, &RuntimeError{Message: "String is too long."}
+			}
+			return strings.Repeat(text, int(count)), nil
+		},
+	})
+
+	// join writes each element as print would.
+	Natives.Register(&NativeFunction{
+		Name:   "join",
+		Params: 2,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			list, err := argument[*LoxList]("join", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			separator, err := argument[string]("join", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			parts := make([]string, 0, len(list.Elements))
+			for _, element := range list.Elements {
+				parts = append(parts, Stringify(element))
+			}
+			return strings.Join(parts, separator), nil
+		},
+	})
+}
@@ -0,0 +1,275 @@
+package runner
+
+import "sort"
+
+type SymbolKind int
+
+const (
+	SymbolVariable SymbolKind = iota
+	SymbolFunction
+	SymbolNative
+)
+
+// Symbol is a declared name and every place it is referenced. Natives have
+// no Declaration or Statement.
+type Symbol struct {
+	Name        string
+	Kind        SymbolKind
+	Declaration Token
+	Statement   Statement
+	References  []Token
+	// Depth is the number of blocks enclosing the declaration, so globals
+	// are at depth zero.
+	Depth int
+}
+
+// SymbolTable resolves names in a program to their declarations using the
+// same scoping rules as the interpreter: locals are visible from their
+// declaration to the end of their block, and globals from anywhere.
+type SymbolTable struct {
+	Symbols []*Symbol
+
+	tokens  map[position]*Symbol
+	globals map[string]*Symbol
+	scopes  []map[string]*Symbol
+	pending []Token
+}
+
+type position struct {
+	line   int
+	column int
+}
+
+func IndexSymbols(stmts []Statement) *SymbolTable {
+	table := &SymbolTable{
+		Symbols: make([]*Symbol, 0),
+		tokens:  make(map[position]*Symbol),
+		globals: make(map[string]*Symbol),
+		scopes:  make([]map[string]*Symbol, 0),
+		pending: make([]Token, 0),
+	}
+
+	for _, stmt := range stmts {
+		table.statement(stmt)
+	}
+
+	// Globals are late bound, so a use may come before its declaration.
+	for _, name := range table.pending {
+		if symbol, ok := table.globals[name.Lexeme]; ok {
+			table.reference(symbol, name)
+		} else if Natives.Defines(name.Lexeme) {
+			symbol := &Symbol{Name: name.Lexeme, Kind: SymbolNative}
+			table.Symbols = append(table.Symbols, symbol)
+			table.globals[name.Lexeme] = symbol
+			table.reference(symbol, name)
+		}
+	}
+
+	for _, symbol := range table.Symbols {
+		references := symbol.References
+		sort.Slice(references, func(a, b int) bool {
+			if references[a].Line != references[b].Line {
+				return references[a].Line < references[b].Line
+			}
+			return references[a].Column < references[b].Column
+		})
+	}
+	return table
+}
+
+// At returns the symbol declared or referenced by the token at line and
+// column, both 1-based, along with that token. tokens may come from a later
+// version of the source than the table, so a token only matches a symbol
+// with the same name.
+func (t *SymbolTable) At(line int, column int, tokens []Token) (*Symbol, Token, bool) {
+	for _, token := range tokens {
+		if token.Type != IDENTIFIER || token.Line != line {
+			continue
+		}
+		if column < token.Column || column > token.Column+len([]rune(token.Lexeme)) {
+			continue
+		}
+		symbol, ok := t.tokens[position{token.Line, token.Column}]
+		if ok && symbol.Name == token.Lexeme {
+			return symbol, token, true
+		}
+	}
+	return nil, Token{}, false
+}
+
+func (t *SymbolTable) declare(name Token, kind SymbolKind, stmt Statement) {
+	symbol := &Symbol{
+		Name:        name.Lexeme,
+		Kind:        kind,
+		Declaration: name,
+		Statement:   stmt,
+		References:  make([]Token, 0),
+		Depth:       len(t.scopes),
+	}
+	t.Symbols = append(t.Symbols, symbol)
+	t.tokens[position{name.Line, name.Column}] = symbol
+
+	if len(t.scopes) == 0 {
+		if _, ok := t.globals[name.Lexeme]; !ok {
+			t.globals[name.Lexeme] = symbol
+		}
+		return
+	}
+	t.scopes[len(t.scopes)-1][name.Lexeme] = symbol
+}
+
+func (t *SymbolTable) resolve(name Token) {
+	for i := len(t.scopes) - 1; i >= 0; i-- {
+		if symbol, ok := t.scopes[i][name.Lexeme]; ok {
+			t.reference(symbol, name)
+			return
+		}
+	}
+	t.pending = append(t.pending, name)
+}
+
+func (t *SymbolTable) reference(symbol *Symbol, name Token) {
+	symbol.References = append(symbol.References, name)
+	t.tokens[position{name.Line, name.Column}] = symbol
+}
+
+func (t *SymbolTable) statement(stmt Statement) {
+	if stmt != nil {
+		stmt.Accept(t)
+	}
+}
+
+func (t *SymbolTable) expression(expr Expression) {
+	if expr != nil {
+		expr.Accept(t)
+	}
+}
+
+func (t *SymbolTable) VisitExpressionStatement(es *ExpressionStatement) {
+	t.expression(es.Expression)
+}
+
+func (t *SymbolTable) VisitPrintStatement(ps *PrintStatement) {
+	t.expression(ps.Expression)
+}
+
+func (t *SymbolTable) VisitVarStatement(vs *VarStatement) {
+	t.expression(vs.Initializer)
+	t.declare(vs.Name, SymbolVariable, vs)
+}
+
+func (t *SymbolTable) VisitBlockStatement(bs *BlockStatement) {
+	t.scopes = append(t.scopes, make(map[string]*Symbol))
+	for _, stmt := range bs.Statements {
+		t.statement(stmt)
+	}
+	t.scopes = t.scopes[:len(t.scopes)-1]
+}
+
+func (t *SymbolTable) VisitIfStatement(is *IfStatement) {
+	t.expression(is.Condition)
+	t.statement(is.ThenBranch)
+	t.statement(is.ElseBranch)
+}
+
+func (t *SymbolTable) VisitFunctionStatement(fs *FunctionStatement) {
+	t.declare(fs.Name, SymbolFunction, fs)
+
+	t.scopes = append(t.scopes, make(map[string]*Symbol))
+	for _, param := range fs.Params {
+		t.declare(param, SymbolVariable, fs)
+	}
+	for _, stmt := range fs.Body.Statements {
+		t.statement(stmt)
+	}
+	t.scopes = t.scopes[:len(t.scopes)-1]
+}
+
+func (t *SymbolTable) VisitReturnStatement(rs *ReturnStatement) {
+	t.expression(rs.Value)
+}
+
+func (t *SymbolTable) VisitBinaryExpression(be *BinaryExpression) interface{} {
+	t.expression(be.Left)
+	t.expression(be.Right)
+	return nil
+}
+
+func (t *SymbolTable) VisitGroupingExpression(ge *GroupingExpression) interface{} {
+	t.expression(ge.Expression)
+	return nil
+}
+
+func (t *SymbolTable) VisitLiteralExpression(le *LiteralExpression) interface{} {
+	return nil
+}
+
+func (t *SymbolTable) VisitUnaryExpression(ue *UnaryExpression) interface{} {
+	t.expression(ue.Right)
+	return nil
+}
+
+func (t *SymbolTable) VisitVarExpression(ve *VarExpression) interface{} {
+	t.resolve(ve.Name)
+	return nil
+}
+
+func (t *SymbolTable) VisitAssignExpression(ae *AssignExpression) interface{} {
+	t.expression(ae.Value)
+	t.resolve(ae.Name)
+	return nil
+}
+
+func (t *SymbolTable) VisitLogicalExpression(le *LogicalExpression) interface{} {
+	t.expression(le.Left)
+	t.expression(le.Right)
+	return nil
+}
+
+func (t *SymbolTable) VisitCallExpression(ce *CallExpression) interface{} {
+	t.expression(ce.Callee)
+	for _, argument := range ce.Arguments {
+		t.expression(argument)
+	}
+	return nil
+}
+
+func (t *SymbolTable) VisitListExpression(le *ListExpression) interface{} {
+	for _, element := range le.Elements {
+		t.expression(element)
+	}
+	return nil
+}
+
+func (t *SymbolTable) VisitIndexExpression(ie *IndexExpression) interface{} {
+	t.expression(ie.Object)
+	t.expression(ie.Index)
+	return nil
+}
+
+func (t *SymbolTable) VisitSetIndexExpression(se *SetIndexExpression) interface{} {
+	t.expression(se.Object)
+	t.expression(se.Index)
+	t.expression(se.Value)
+	return nil
+}
+
+func (t *SymbolTable) VisitMapExpression(me *MapExpression) interface{} {
+	for index, key := range me.Keys {
+		t.expression(key)
+		t.expression(me.Values[index])
+	}
+	return nil
+}
+
+func (t *SymbolTable) VisitInterpolationExpression(ie *InterpolationExpression) interface{} {
+	for _, expr := range ie.Expressions {
+		t.expression(expr)
+	}
+	return nil
+}
+
+func (t *SymbolTable) VisitGetExpression(ge *GetExpression) interface{} {
+	t.expression(ge.Object)
+	return nil
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ExitError is the cause of the RuntimeError raised by exit(code). It stops
+// the program like any runtime error, but runners report it as an exit
+// status rather than a failure.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %v", e.Code)
+}
+
+// isExit reports whether err is a program calling exit.
+func isExit(err error) bool {
+	var exit *ExitError
+	return errors.As(err, &exit)
+}
+
+// readInput reads a line from the interpreter's input without its line
+// ending, returning nil at the end of the input.
+func (i *Interpreter) readInput(name string) (interface{}, error) {
+	if i.input == nil {
+		// An Input that is already a bufio.Reader, like the piped REPL's,
+		// comes back as is, so the two don't buffer lines from each other.
+		i.input = bufio.NewReader(i.Input)
+	}
+	line, err := i.input.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, &RuntimeError{Message: fmt.Sprintf("%v failed: %v.", name, err), Err: err}
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func init() {
+	// input writes prompt and reads a line, like readLine.
+	Natives.Register(&NativeFunction{
+		Name:   "input",
+		Params: 1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			prompt, err := argument[string]("input", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprint(interpreter.Output, prompt)
+			return interpreter.readInput("input")
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:   "readLine",
+		Params: 0,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return interpreter.readInput("readLine")
+		},
+	})
+
+	// getenv returns nil for variables that aren't set.
+	Natives.Register(&NativeFunction{
+		Name:     "getenv",
+		Params:   1,
+		Requires: CapEnv,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			name, err := argument[string]("getenv", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return nil, nil
+			}
+			return value, nil
+		},
+	})
+
+	Natives.Register(&NativeFunction{
+		Name:     "setenv",
+		Params:   2,
+		Requires: CapEnv,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			name, err := argument[string]("setenv", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			value, err := argument[string]("setenv", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := os.Setenv(name, value); err != nil {
+				return nil, &RuntimeError{Message: fmt.Sprintf("setenv failed for '%v': %v.", name, err), Err: err}
+			}
+			return nil, nil
+		},
+	})
+
+	// exit stops the program with an exit status, 0 if none is given.
+	Natives.Register(&NativeFunction{
+		Name:   "exit",
+		Params: -1,
+		Fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if err := checkArgumentCount("exit", arguments, 0, 1); err != nil {
+				return nil, err
+			}
+			code := 0.0
+			if len(arguments) == 1 {
+				var err error
+				code, err = integerArgument("exit", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+				if code < 0 || code > 255 {
+					return nil, invalidArgument("exit", 0, "between 0 and 255")
+				}
+			}
+			return nil, &RuntimeError{
+				Message: fmt.Sprintf("Exited with status %v.", code),
+				Err:     &ExitError{Code: int(code)},
+			}
+		},
+	})
+}
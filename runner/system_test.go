@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestArgsAndInput(t *testing.T) {
+	var output bytes.Buffer
+	r := LoxRunner{
+		Output: &output,
+		Args:   []string{"one", "two"},
+		Input:  strings.NewReader("Ada\r\nsecond\n"),
+	}
+	err := r.Run(context.Background(), `
+print args;
+var name = input("Name? ");
+print "Hello, ${name}.";
+print readLine();
+print readLine();
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[\"one\", \"two\"]\nName? Hello, Ada.\nsecond\nnil\n"
+	if output.String() != want {
+		t.Errorf("output = %q, want %q", output.String(), want)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("LOX_TEST_VARIABLE", "before")
+	var output bytes.Buffer
+	r := LoxRunner{Output: &output, Capabilities: Capabilities{Env: true}}
+	err := r.Run(context.Background(), `
+print getenv("LOX_TEST_VARIABLE");
+setenv("LOX_TEST_VARIABLE", "after");
+print getenv("LOX_TEST_VARIABLE");
+print getenv("LOX_TEST_UNSET_VARIABLE");
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "before\nafter\nnil\n"; output.String() != want {
+		t.Errorf("output = %q, want %q", output.String(), want)
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		program string
+		code    int
+	}{
+		{`exit(); print "unreachable";`, 0},
+		{`exit(3);`, 3},
+		// assertThrows can't catch exit.
+		{`fun f() { exit(4); } assertThrows(f);`, 4},
+	}
+	for _, test := range tests {
+		var output bytes.Buffer
+		r := LoxRunner{Output: &output, ErrorOutput: io.Discard}
+		err := r.Run(context.Background(), test.program)
+
+		var exit *ExitError
+		if !errors.As(err, &exit) || exit.Code != test.code {
+			t.Errorf("%v: error = %v, want exit status %v", test.program, err, test.code)
+		}
+		if !r.Exited || r.ExitCode != test.code || r.HadRuntimeError {
+			t.Errorf("%v: Exited = %v, ExitCode = %v, HadRuntimeError = %v", test.program, r.Exited, r.ExitCode, r.HadRuntimeError)
+		}
+		if output.Len() != 0 {
+			t.Errorf("%v: printed %q", test.program, output.String())
+		}
+	}
+}
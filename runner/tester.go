@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// TestPrefix starts the name of every function RunTests treats as a test.
+const TestPrefix = "test_"
+
+// TestResult is the outcome of one test function. Err is nil when the test
+// passed; failed assertions wrap ErrAssertion.
+type TestResult struct {
+	File     string
+	Name     string
+	Line     int
+	Duration time.Duration
+	// Output is what the test printed.
+	Output string
+	Err    error
+}
+
+func (t TestResult) Passed() bool {
+	return t.Err == nil
+}
+
+// Failed reports whether the test ran and an assertion failed, as opposed
+// to stopping with some other error.
+func (t TestResult) Failed() bool {
+	return errors.Is(t.Err, ErrAssertion)
+}
+
+// TestFunctions returns the top-level functions in stmts named as tests, in
+// the order they are declared.
+func TestFunctions(stmts []Statement) []*FunctionStatement {
+	tests := make([]*FunctionStatement, 0)
+	for _, stmt := range stmts {
+		function, ok := stmt.(*FunctionStatement)
+		if ok && strings.HasPrefix(function.Name.Lexeme, TestPrefix) && len(function.Params) == 0 {
+			tests = append(tests, function)
+		}
+	}
+	return tests
+}
+
+// RunTests runs every test function in the file at path. Each test gets a
+// fresh interpreter, so its globals are isolated from the other tests'. A
+// file that doesn't parse gives a single result named after the file.
+func (r *LoxRunner) RunTests(ctx context.Context, path string) ([]TestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	errorOutput := r.ErrorOutput
+	r.ErrorOutput = io.Discard
+	stmts := r.Parse(string(data))
+	r.ErrorOutput = errorOutput
+	if r.HadError {
+		messages := make([]string, 0, len(r.Errors))
+		for _, syntaxError := range r.Errors {
+			messages = append(messages, syntaxError.Error())
+		}
+		return []TestResult{{
+			File: path,
+			Name: path,
+			Err:  errors.New(strings.Join(messages, "\n")),
+		}}, nil
+	}
+
+	if r.Coverage != nil {
+		r.Coverage.add(stmts)
+	}
+
+	results := make([]TestResult, 0)
+	for _, test := range TestFunctions(stmts) {
+		results = append(results, r.runTest(ctx, path, stmts, test))
+	}
+	return results, nil
+}
+
+func (r *LoxRunner) runTest(ctx context.Context, path string, stmts []Statement, test *FunctionStatement) TestResult {
+	var output bytes.Buffer
+	interpreter := r.newInterpreter()
+	interpreter.Output = &output
+	defer interpreter.closeFiles()
+
+	start := time.Now()
+	err := interpreter.interpret(ctx, stmts)
+	if err == nil {
+		call := &CallExpression{Callee: &VarExpression{Name: test.Name}, Paren: test.Name}
+		_, err = interpreter.evaluateTop(ctx, call)
+	}
+
+	return TestResult{
+		File:     path,
+		Name:     test.Name.Lexeme,
+		Line:     test.Name.Line,
+		Duration: time.Since(start),
+		Output:   output.String(),
+		Err:      err,
+	}
+}
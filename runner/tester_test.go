@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testerSource = `
+var runs = 0;
+
+fun test_pass() {
+  runs = runs + 1;
+  assertEqual(runs, 1);
+}
+
+fun test_isolated() {
+  runs = runs + 1;
+  assertEqual(runs, 1);
+}
+
+fun test_fail() {
+  assert(false);
+}
+
+fun test_error() {
+  nil();
+}
+
+fun helper() {}
+fun test_with_params(a) {}
+`
+
+func TestRunTests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example_test.lox")
+	if err := os.WriteFile(path, []byte(testerSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := LoxRunner{}
+	results, err := r.RunTests(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name   string
+		passed bool
+		failed bool
+	}{
+		{"test_pass", true, false},
+		{"test_isolated", true, false},
+		{"test_fail", false, true},
+		{"test_error", false, false},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %v results, want %v", len(results), len(want))
+	}
+	for index, result := range results {
+		if result.Name != want[index].name || result.Passed() != want[index].passed || result.Failed() != want[index].failed {
+			t.Errorf("result %v = %v passed=%v failed=%v (%v), want %+v",
+				index, result.Name, result.Passed(), result.Failed(), result.Err, want[index])
+		}
+	}
+}
+
+func TestRunTestsSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken_test.lox")
+	if err := os.WriteFile(path, []byte("fun test_x() {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := LoxRunner{}
+	results, err := r.RunTests(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Passed() {
+		t.Fatalf("got %+v, want a single failed result", results)
+	}
+}
+
+func TestRunTestsCoverage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "covered_test.lox")
+	source := `
+fun half(n) {
+  if (n > 0) return n / 2;
+  return 0;
+}
+
+fun test_half() {
+  assertEqual(half(4), 2);
+}
+`
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := LoxRunner{Coverage: NewCoverage(path)}
+	if _, err := r.RunTests(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	want := CoverageSummary{
+		Statements: 6, StatementsHit: 5,
+		Branches: 2, BranchesHit: 1,
+		Functions: 2, FunctionsHit: 2,
+	}
+	if got := r.Coverage.Summary(); got != want {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
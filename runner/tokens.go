@@ -13,15 +13,20 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
+	COLON
 	DOT
 	MINUS
 	PLUS
 	SEMICOLON
 	SLASH
-	STAR
+	PERCENT
 
 	// One or two character tokens.
+	STAR
+	STAR_STAR
 	BANG
 	BANG_EQUAL
 	EQUAL
@@ -34,6 +39,10 @@ const (
 	// Literals.
 	IDENTIFIER
 	STRING
+	// INTERPOLATION is the part of a string literal before an interpolated
+	// expression. The string goes on after the expression as another
+	// INTERPOLATION or, at its end, a STRING.
+	INTERPOLATION
 	NUMBER
 
 	// Keywords.
@@ -54,6 +63,9 @@ const (
 	VAR
 	WHILE
 
+	// Trivia, recorded by the scanner but kept out of Tokens.
+	COMMENT
+
 	EOF
 	_
 )
@@ -64,6 +76,7 @@ var KeyMap = map[string]TokenType{
 	"else":   ELSE,
 	"false":  FALSE,
 	"for":    FOR,
+	"fun":    FUN,
 	"if":     IF,
 	"nil":    NIL,
 	"or":     OR,
@@ -82,6 +95,8 @@ type Token struct {
 	Lexeme string
 	Value  interface{}
 	Line   int
+	// Column is the 1-based column on which the token starts.
+	Column int
 }
 
 func (t *Token) ToString() string {
@@ -12,46 +12,53 @@ func _() {
 	_ = x[RIGHT_PAREN-1]
 	_ = x[LEFT_BRACE-2]
 	_ = x[RIGHT_BRACE-3]
-	_ = x[COMMA-4]
-	_ = x[DOT-5]
-	_ = x[MINUS-6]
-	_ = x[PLUS-7]
-	_ = x[SEMICOLON-8]
-	_ = x[SLASH-9]
-	_ = x[STAR-10]
-	_ = x[BANG-11]
-	_ = x[BANG_EQUAL-12]
-	_ = x[EQUAL-13]
-	_ = x[EQUAL_EQUAL-14]
-	_ = x[GREATER-15]
-	_ = x[GREATER_EQUAL-16]
-	_ = x[LESS-17]
-	_ = x[LESS_EQUAL-18]
-	_ = x[IDENTIFIER-19]
-	_ = x[STRING-20]
-	_ = x[NUMBER-21]
-	_ = x[AND-22]
-	_ = x[CLASS-23]
-	_ = x[ELSE-24]
-	_ = x[FALSE-25]
-	_ = x[FUN-26]
-	_ = x[FOR-27]
-	_ = x[IF-28]
-	_ = x[NIL-29]
-	_ = x[OR-30]
-	_ = x[PRINT-31]
-	_ = x[RETURN-32]
-	_ = x[SUPER-33]
-	_ = x[THIS-34]
-	_ = x[TRUE-35]
-	_ = x[VAR-36]
-	_ = x[WHILE-37]
-	_ = x[EOF-38]
+	_ = x[LEFT_BRACKET-4]
+	_ = x[RIGHT_BRACKET-5]
+	_ = x[COMMA-6]
+	_ = x[COLON-7]
+	_ = x[DOT-8]
+	_ = x[MINUS-9]
+	_ = x[PLUS-10]
+	_ = x[SEMICOLON-11]
+	_ = x[SLASH-12]
+	_ = x[PERCENT-13]
+	_ = x[STAR-14]
+	_ = x[STAR_STAR-15]
+	_ = x[BANG-16]
+	_ = x[BANG_EQUAL-17]
+	_ = x[EQUAL-18]
+	_ = x[EQUAL_EQUAL-19]
+	_ = x[GREATER-20]
+	_ = x[GREATER_EQUAL-21]
+	_ = x[LESS-22]
+	_ = x[LESS_EQUAL-23]
+	_ = x[IDENTIFIER-24]
+	_ = x[STRING-25]
+	_ = x[INTERPOLATION-26]
+	_ = x[NUMBER-27]
+	_ = x[AND-28]
+	_ = x[CLASS-29]
+	_ = x[ELSE-30]
+	_ = x[FALSE-31]
+	_ = x[FUN-32]
+	_ = x[FOR-33]
+	_ = x[IF-34]
+	_ = x[NIL-35]
+	_ = x[OR-36]
+	_ = x[PRINT-37]
+	_ = x[RETURN-38]
+	_ = x[SUPER-39]
+	_ = x[THIS-40]
+	_ = x[TRUE-41]
+	_ = x[VAR-42]
+	_ = x[WHILE-43]
+	_ = x[COMMENT-44]
+	_ = x[EOF-45]
 }
 
-const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACECOMMADOTMINUSPLUSSEMICOLONSLASHSTARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTIFIERSTRINGNUMBERANDCLASSELSEFALSEFUNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILEEOF"
+const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACELEFT_BRACKETRIGHT_BRACKETCOMMACOLONDOTMINUSPLUSSEMICOLONSLASHPERCENTSTARSTAR_STARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTIFIERSTRINGINTERPOLATIONNUMBERANDCLASSELSEFALSEFUNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILECOMMENTEOF"
 
-var _TokenType_index = [...]uint8{0, 10, 21, 31, 42, 47, 50, 55, 59, 68, 73, 77, 81, 91, 96, 107, 114, 127, 131, 141, 151, 157, 163, 166, 171, 175, 180, 183, 186, 188, 191, 193, 198, 204, 209, 213, 217, 220, 225, 228}
+var _TokenType_index = [...]uint16{0, 10, 21, 31, 42, 54, 67, 72, 77, 80, 85, 89, 98, 103, 110, 114, 123, 127, 137, 142, 153, 160, 173, 177, 187, 197, 203, 216, 222, 225, 230, 234, 239, 242, 245, 247, 250, 252, 257, 263, 268, 272, 276, 279, 284, 291, 294}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+type TraceFormat int
+
+const (
+	// TraceText writes one readable line per event, indented by call depth.
+	TraceText TraceFormat = iota
+	// TraceJSON writes one JSON object per event, as JSON Lines.
+	TraceJSON
+)
+
+// Tracer logs a program as it runs: every statement executed, every
+// variable or parameter defined or assigned with its old and new values,
+// every call with its arguments and return value, and the runtime error that
+// stops the program, if any. Set one on a LoxRunner before running a
+// program.
+type Tracer struct {
+	Output io.Writer
+	Format TraceFormat
+
+	// sources caches the text shown for each statement.
+	sources map[Statement]string
+}
+
+func NewTracer(output io.Writer, format TraceFormat) *Tracer {
+	return &Tracer{
+		Output:  output,
+		Format:  format,
+		sources: make(map[Statement]string),
+	}
+}
+
+func (t *Tracer) statement(interpreter *Interpreter, stmt Statement, line int) {
+	source, ok := t.sources[stmt]
+	if !ok {
+		formatter := Formatter{}
+		source, _, _ = strings.Cut(strings.TrimSpace(formatter.Format([]Statement{stmt})), "\n")
+		t.sources[stmt] = source
+	}
+
+	depth := interpreter.callDepth()
+	if t.Format == TraceJSON {
+		t.writeJSON(node{"event": "statement", "line": line, "depth": depth, "source": source})
+		return
+	}
+	t.writeText(line, depth, source)
+}
+
+func (t *Tracer) define(interpreter *Interpreter, name string, value interface{}) {
+	line, depth := interpreter.currentLine(), interpreter.callDepth()
+	if t.Format == TraceJSON {
+		t.writeJSON(node{"event": "define", "line": line, "depth": depth, "name": name, "value": traceValue(value)})
+		return
+	}
+	t.writeText(line, depth+1, fmt.Sprintf("define %v = %v", name, describe(value)))
+}
+
+func (t *Tracer) assign(interpreter *Interpreter, name string, old interface{}, value interface{}) {
+	line, depth := interpreter.currentLine(), interpreter.callDepth()
+	if t.Format == TraceJSON {
+		t.writeJSON(node{"event": "assign", "line": line, "depth": depth, "name": name, "old": traceValue(old), "value": traceValue(value)})
+		return
+	}
+	t.writeText(line, depth+1, fmt.Sprintf("assign %v = %v (was %v)", name, describe(value), describe(old)))
+}
+
+func (t *Tracer) call(interpreter *Interpreter, line int, callee LoxCallable, arguments []interface{}) {
+	name, depth := calleeName(callee), interpreter.callDepth()
+	if t.Format == TraceJSON {
+		values := make([]interface{}, 0, len(arguments))
+		for _, argument := range arguments {
+			values = append(values, traceValue(argument))
+		}
+		t.writeJSON(node{"event": "call", "line": line, "depth": depth, "name": name, "arguments": values})
+		return
+	}
+	described := make([]string, 0, len(arguments))
+	for _, argument := range arguments {
+		described = append(described, describe(argument))
+	}
+	t.writeText(line, depth+1, fmt.Sprintf("call %v(%v)", name, strings.Join(described, ", ")))
+}
+
+func (t *Tracer) exit(interpreter *Interpreter, line int, callee LoxCallable, value interface{}) {
+	name, depth := calleeName(callee), interpreter.callDepth()
+	if t.Format == TraceJSON {
+		t.writeJSON(node{"event": "return", "line": line, "depth": depth, "name": name, "value": traceValue(value)})
+		return
+	}
+	t.writeText(line, depth+1, fmt.Sprintf("return %v -> %v", name, describe(value)))
+}
+
+// fail logs the runtime error that stopped the program. Exits aren't
+// errors and aren't logged.
+func (t *Tracer) fail(interpreter *Interpreter, err *RuntimeError) {
+	depth := interpreter.callDepth()
+	if t.Format == TraceJSON {
+		t.writeJSON(node{"event": "error", "line": err.Line, "depth": depth, "message": err.Message})
+		return
+	}
+	t.writeText(err.Line, depth+1, "error "+err.Message)
+}
+
+func (t *Tracer) writeText(line int, depth int, text string) {
+	fmt.Fprintf(t.Output, "[line %v] %v%v\n", line, strings.Repeat("  ", depth), text)
+}
+
+func (t *Tracer) writeJSON(event node) {
+	encoder := json.NewEncoder(t.Output)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(event)
+}
+
+// traceValue converts a Lox value to one encoding/json can write. Values
+// JSON has no literal for, such as functions and infinities, are written
+// as they print.
+func traceValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case nil, bool, string:
+		return value
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return Stringify(value)
+		}
+		return value
+	}
+	return Stringify(value)
+}
+
+func calleeName(callee LoxCallable) string {
+	switch function := callee.(type) {
+	case *LoxFunction:
+		return function.Declaration.Name.Lexeme
+	case *NativeFunction:
+		return function.Name
+	}
+	return Stringify(callee)
+}
+
+// callDepth is the number of Lox function calls in progress.
+func (i *Interpreter) callDepth() int {
+	return len(i.frames) - 1
+}
+
+// currentLine is the line of the statement running in the innermost call.
+func (i *Interpreter) currentLine() int {
+	return i.frames[len(i.frames)-1].Line
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+const tracedSource = `var a = 1;
+fun double(n) {
+  return n * 2;
+}
+a = double(a);
+`
+
+func TestTraceText(t *testing.T) {
+	var trace bytes.Buffer
+	r := LoxRunner{Output: io.Discard, Tracer: NewTracer(&trace, TraceText)}
+	if err := r.Run(context.Background(), tracedSource); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[line 1] var a = 1;
+[line 1]   define a = 1
+[line 2] fun double(n) {
+[line 2]   define double = <fn double>
+[line 5] a = double(a);
+[line 5]   call double(1)
+[line 5]   define n = 1
+[line 3]   return n * 2;
+[line 5]   return double -> 2
+[line 5]   assign a = 2 (was 1)
+`
+	if trace.String() != want {
+		t.Errorf("trace =\n%v\nwant\n%v", trace.String(), want)
+	}
+}
+
+func TestTraceJSON(t *testing.T) {
+	var trace bytes.Buffer
+	r := LoxRunner{Output: io.Discard, Tracer: NewTracer(&trace, TraceJSON)}
+	if err := r.Run(context.Background(), tracedSource); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(trace.String()), "\n") {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		events = append(events, event["event"].(string))
+		if event["event"] == "assign" && (event["old"] != 1.0 || event["value"] != 2.0) {
+			t.Errorf("assign event = %v, want old 1 and value 2", event)
+		}
+	}
+
+	want := "statement define statement define statement call define statement return assign"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestTraceError(t *testing.T) {
+	source := `fun check(n) {
+  return n + nil;
+}
+check(1);
+`
+	var text, events bytes.Buffer
+	for format, trace := range map[TraceFormat]*bytes.Buffer{TraceText: &text, TraceJSON: &events} {
+		r := LoxRunner{Output: io.Discard, ErrorOutput: io.Discard, Tracer: NewTracer(trace, format)}
+		if err := r.Run(context.Background(), source); err == nil {
+			t.Fatal("want a runtime error")
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	want := "[line 2]   error Operands must be two numbers or two strings."
+	if got := lines[len(lines)-1]; got != want {
+		t.Errorf("last text event = %q, want %q", got, want)
+	}
+	lines = strings.Split(strings.TrimSpace(events.String()), "\n")
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event["event"] != "error" || event["line"] != 2.0 || event["message"] == "" {
+		t.Errorf("last JSON event = %v, want an error on line 2", event)
+	}
+
+	// Exiting isn't an error.
+	var trace bytes.Buffer
+	r := LoxRunner{Output: io.Discard, Tracer: NewTracer(&trace, TraceText)}
+	r.Run(context.Background(), "exit(2);")
+	if strings.Contains(trace.String(), "error") {
+		t.Errorf("exit traced as an error:\n%v", trace.String())
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+// testSuffix marks the files the test command looks in.
+const testSuffix = "_test.lox"
+
+func testCommand(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text, tap or junit")
+	timeout := flags.Duration("timeout", 0, "wall time allowed for each test, e.g. 5s (0 means no limit)")
+	coverage := flags.String("coverage", "", "record coverage, writing lcov.info and an HTML page per file to this directory")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox test [-format text|tap|junit] [-timeout d] [-coverage dir] path ...")
+		fmt.Fprintf(flags.Output(), "Runs the test_* functions in every *%v file under each path.\n", testSuffix)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var report func(io.Writer, []runner.TestResult) error
+	switch *format {
+	case "text":
+		report = reportText
+	case "tap":
+		report = reportTAP
+	case "junit":
+		report = reportJUnit
+	default:
+		fmt.Fprintf(os.Stderr, "go-lox test: unknown format %q\n", *format)
+		return 64
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 0
+	results := make([]runner.TestResult, 0)
+	coverages := make([]*runner.Coverage, 0)
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (file != path && !strings.HasSuffix(file, testSuffix)) {
+				return nil
+			}
+			lox := runner.LoxRunner{Limits: runner.Limits{Timeout: *timeout}}
+			if *coverage != "" {
+				lox.Coverage = runner.NewCoverage(file)
+				coverages = append(coverages, lox.Coverage)
+			}
+			fileResults, err := lox.RunTests(context.Background(), file)
+			results = append(results, fileResults...)
+			return err
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 66
+		}
+	}
+
+	if err := report(os.Stdout, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *coverage != "" {
+		if err := writeTestCoverage(coverages, *coverage); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	for _, result := range results {
+		if !result.Passed() && status == 0 {
+			status = 1
+		}
+	}
+	return status
+}
+
+func reportText(out io.Writer, results []runner.TestResult) error {
+	passed, failed := 0, 0
+	for _, result := range results {
+		if result.Passed() {
+			passed++
+			fmt.Fprintf(out, "PASS %v: %v (%v)\n", result.File, result.Name, milliseconds(result.Duration))
+			continue
+		}
+		failed++
+		fmt.Fprintf(out, "FAIL %v: %v (%v)\n", result.File, result.Name, milliseconds(result.Duration))
+		fmt.Fprintln(out, indentLines(result.Err.Error(), "    "))
+		if result.Output != "" {
+			fmt.Fprintln(out, "    output:")
+			fmt.Fprintln(out, indentLines(strings.TrimSuffix(result.Output, "\n"), "      "))
+		}
+	}
+
+	verdict := "ok"
+	if failed > 0 {
+		verdict = "FAIL"
+	}
+	_, err := fmt.Fprintf(out, "%v: %v passed, %v failed\n", verdict, passed, failed)
+	return err
+}
+
+// reportTAP writes results in the Test Anything Protocol, version 13, with
+// failures described in YAML blocks.
+func reportTAP(out io.Writer, results []runner.TestResult) error {
+	fmt.Fprintln(out, "TAP version 13")
+	fmt.Fprintf(out, "1..%v\n", len(results))
+	for index, result := range results {
+		if result.Passed() {
+			fmt.Fprintf(out, "ok %v - %v: %v\n", index+1, result.File, result.Name)
+			continue
+		}
+		fmt.Fprintf(out, "not ok %v - %v: %v\n", index+1, result.File, result.Name)
+		fmt.Fprintln(out, "  ---")
+		fmt.Fprintln(out, "  message: |")
+		fmt.Fprintln(out, indentLines(result.Err.Error(), "    "))
+		severity := "error"
+		if result.Failed() {
+			severity = "fail"
+		}
+		fmt.Fprintf(out, "  severity: %v\n", severity)
+		fmt.Fprintf(out, "  duration_ms: %.3f\n", float64(result.Duration)/float64(time.Millisecond))
+		if result.Output != "" {
+			fmt.Fprintln(out, "  output: |")
+			fmt.Fprintln(out, indentLines(strings.TrimSuffix(result.Output, "\n"), "    "))
+		}
+		fmt.Fprintln(out, "  ...")
+	}
+	return nil
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// reportJUnit writes results as JUnit XML with one suite per file.
+func reportJUnit(out io.Writer, results []runner.TestResult) error {
+	report := junitSuites{Suites: make([]junitSuite, 0)}
+	durations := make(map[string]time.Duration)
+	for _, result := range results {
+		if len(report.Suites) == 0 || report.Suites[len(report.Suites)-1].Name != result.File {
+			report.Suites = append(report.Suites, junitSuite{Name: result.File})
+		}
+		suite := &report.Suites[len(report.Suites)-1]
+
+		testCase := junitCase{
+			Name:      result.Name,
+			Classname: strings.TrimSuffix(filepath.ToSlash(result.File), ".lox"),
+			Time:      seconds(result.Duration),
+			SystemOut: result.Output,
+		}
+		if !result.Passed() {
+			message, _, _ := strings.Cut(result.Err.Error(), "\n")
+			problem := &junitProblem{Message: message, Text: result.Err.Error()}
+			if result.Failed() {
+				testCase.Failure = problem
+				suite.Failures++
+			} else {
+				testCase.Error = problem
+				suite.Errors++
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		durations[suite.Name] += result.Duration
+	}
+
+	for index := range report.Suites {
+		suite := &report.Suites[index]
+		suite.Time = seconds(durations[suite.Name])
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	fmt.Fprint(out, xml.Header)
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
+
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func indentLines(text string, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rdtharri/go-lox/runner"
+)
+
+func tokensCommand(args []string) int {
+	flags := flag.NewFlagSet("tokens", flag.ExitOnError)
+	source := flags.String("e", "", "scan this source instead of a file")
+	asJSON := flags.Bool("json", false, "print the tokens as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-lox tokens [-json] [-e source | file]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *source == "" {
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return 64
+		}
+		data, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 66
+		}
+		*source = string(data)
+	}
+
+	lox := runner.LoxRunner{ErrorOutput: os.Stderr}
+	tokens := lox.Scan(*source)
+
+	if *asJSON {
+		if code := printJSON(json.MarshalIndent(tokens, "", "  ")); code != 0 {
+			return code
+		}
+	} else {
+		for _, token := range tokens {
+			fmt.Printf("%v:%v\t%v\n", token.Line, token.Column, token.ToString())
+		}
+	}
+
+	if lox.HadError {
+		return 65
+	}
+	return 0
+}